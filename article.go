@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// articleHTTPClient fetches linked articles; callers bound each request with a context
+var articleHTTPClient = &http.Client{}
+
+// articleNoise are elements that never hold the article text
+const articleNoise = "script, style, noscript, template, iframe, svg, form, nav, header, footer, aside, " +
+	"[role=navigation], [role=banner], [role=contentinfo], [aria-hidden=true]"
+
+// minParagraphLength is the length below which a paragraph is treated as boilerplate
+const minParagraphLength = 25
+
+// pageInfo is what fetching a linked page yields for its item
+type pageInfo struct {
+	OpenGraph      OpenGraph `json:"open_graph"`
+	ReadingMinutes int       `json:"reading_minutes,omitempty"`
+	Summary        string    `json:"summary,omitempty"`
+}
+
+// articleFetch fetches the linked pages for enrichment and summaries; either may be nil.
+// With both enabled, the enrichment settings apply, with the longer timeout and the larger
+// size limit of the two.
+type articleFetch struct {
+	enrich  *EnrichConfig
+	summary *SummaryConfig
+
+	timeout     time.Duration
+	maxBytes    int64
+	concurrency int
+	perDomain   int
+	cache       *fileCache
+}
+
+func newArticleFetch(enrich *EnrichConfig, summary *SummaryConfig) *articleFetch {
+	f := &articleFetch{}
+	if summary != nil {
+		cfg := summary.withDefaults()
+		f.summary = &cfg
+		f.timeout, f.maxBytes = cfg.Timeout, cfg.MaxBytes
+		// Summaries alone aren't limited per site
+		f.concurrency, f.perDomain = cfg.Concurrency, cfg.Concurrency
+	}
+	if enrich != nil {
+		cfg := enrich.withDefaults()
+		f.enrich = &cfg
+		f.timeout, f.maxBytes = max(f.timeout, cfg.Timeout), max(f.maxBytes, cfg.MaxBytes)
+		f.concurrency, f.perDomain = cfg.Concurrency, cfg.PerDomain
+		if !cfg.NoCache && cfg.CacheDir != "" {
+			f.cache = newFileCache(cfg.CacheDir, cfg.CacheTTL)
+		}
+	}
+	return f
+}
+
+// fetchArticles fetches the page of every item once and sets what the enabled steps take
+// from it: the Open Graph metadata with enrichment, the summary with summaries, and the
+// reading time with either. Fresh results come from the enrichment cache. Pages that can't
+// be fetched are logged and their items left as they were.
+func fetchArticles(ctx context.Context, items []Item, enrich *EnrichConfig, summary *SummaryConfig) {
+	f := newArticleFetch(enrich, summary)
+	limiter := newDomainLimiter(f.perDomain)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, f.concurrency)
+	for i := range items {
+		link := items[i].Link
+		if link == "" {
+			continue
+		}
+		var page pageInfo
+		if f.cache.get(f.cacheKey(link), &page) {
+			page.apply(&items[i])
+			continue
+		}
+		// Warnings about the page name the item's source along with its link
+		ctx := withLogger(ctx, loggerFrom(ctx).With("source", items[i].Source))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Take the site's slot first so one busy site doesn't hold up the others
+			release := limiter.acquire(linkDomain(link))
+			defer release()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			page, err := f.fetch(ctx, link)
+			if err != nil {
+				loggerFrom(ctx).Warn("failed to fetch article", "link", link, "error", err)
+				return
+			}
+			page.apply(&items[i])
+			if err := f.cache.put(f.cacheKey(link), page); err != nil {
+				loggerFrom(ctx).Warn("failed to cache page info", "link", link, "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// cacheKey tells the cached pages apart by the summary settings they were made with
+func (f *articleFetch) cacheKey(link string) string {
+	if f.summary == nil {
+		return link
+	}
+	return fmt.Sprintf("%s\nsummary %d %d", link, f.summary.Sentences, f.summary.MaxLength)
+}
+
+func (f *articleFetch) fetch(ctx context.Context, link string) (pageInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	doc, err := fetchArticle(ctx, articleHTTPClient, link, f.maxBytes)
+	if err != nil {
+		return pageInfo{}, err
+	}
+	var page pageInfo
+	// Extracting the text removes elements from doc, so the metadata is read first
+	if f.enrich != nil {
+		page.OpenGraph = parseOpenGraph(doc, link)
+	}
+	text := extractMainText(doc)
+	page.ReadingMinutes = readingMinutes(text)
+	if f.summary != nil {
+		if summary := summarizeText(text, f.summary.Sentences); summary != "" {
+			page.Summary = truncateText(f.summary.MaxLength, summary)
+		} else {
+			loggerFrom(ctx).Warn("no summary", "link", link, "error", "no article text found")
+		}
+	}
+	return page, nil
+}
+
+// apply sets what was learned about the page on its item
+func (p pageInfo) apply(item *Item) {
+	item.OpenGraph, item.ReadingMinutes, item.Summary = p.OpenGraph, p.ReadingMinutes, p.Summary
+}
+
+// fetchArticle downloads the HTML page at link, reading at most maxBytes
+func fetchArticle(ctx context.Context, client *http.Client, link string, maxBytes int64) (*goquery.Document, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make NewRequest: %w", err)
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch article: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("not an HTML page: %s", mediaType)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML: %w", err)
+	}
+	return doc, nil
+}
+
+// extractMainText returns the paragraphs of the page's main content, one per line.
+// It prefers an <article> or <main> element and otherwise picks, readability-style,
+// the element whose direct paragraphs hold the most text.
+func extractMainText(doc *goquery.Document) string {
+	doc.Find(articleNoise).Remove()
+
+	root := doc.Find("article").First()
+	if root.Length() == 0 {
+		root = doc.Find(`main, [role=main]`).First()
+	}
+	if root.Length() == 0 {
+		root = bestContentBlock(doc)
+	}
+	if root.Length() == 0 {
+		root = doc.Find("body")
+	}
+
+	var paragraphs []string
+	root.Find("p, li, blockquote").Each(func(_ int, p *goquery.Selection) {
+		// Nested blocks are collected through their own element
+		if p.Find("p").Length() > 0 {
+			return
+		}
+		text := strings.Join(strings.Fields(p.Text()), " ")
+		if utf8.RuneCountInString(text) >= minParagraphLength {
+			paragraphs = append(paragraphs, text)
+		}
+	})
+	return strings.Join(paragraphs, "\n")
+}
+
+// bestContentBlock scores the parents of every paragraph by the text they hold, with
+// a share going to the grandparent, and returns the highest scoring element
+func bestContentBlock(doc *goquery.Document) *goquery.Selection {
+	type candidate struct {
+		sel   *goquery.Selection
+		score float64
+	}
+	var candidates []*candidate
+	byNode := make(map[any]*candidate)
+	add := func(sel *goquery.Selection, score float64) {
+		if sel.Length() == 0 {
+			return
+		}
+		node := sel.Get(0)
+		c, ok := byNode[node]
+		if !ok {
+			c = &candidate{sel: sel}
+			byNode[node] = c
+			candidates = append(candidates, c)
+		}
+		c.score += score
+	}
+
+	doc.Find("p").Each(func(_ int, p *goquery.Selection) {
+		text := strings.Join(strings.Fields(p.Text()), " ")
+		length := utf8.RuneCountInString(text)
+		if length < minParagraphLength {
+			return
+		}
+		// One point per paragraph, per comma and per 100 characters up to three
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "、")) + min(float64(length)/100, 3)
+		parent := p.Parent()
+		add(parent, score)
+		add(parent.Parent(), score/2)
+	})
+
+	var best *candidate
+	for _, c := range candidates {
+		if best == nil || c.score > best.score {
+			best = c
+		}
+	}
+	if best == nil {
+		return &goquery.Selection{}
+	}
+	return best.sel
+}
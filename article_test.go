@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractMainText(t *testing.T) {
+	t.Run("picks the block holding the most paragraph text", func(t *testing.T) {
+		file, err := os.Open("testdata/article.html")
+		require.NoError(t, err)
+		defer file.Close()
+		doc, err := goquery.NewDocumentFromReader(file)
+		require.NoError(t, err)
+
+		paragraphs := strings.Split(extractMainText(doc), "\n")
+
+		require.Len(t, paragraphs, 4)
+		assert.True(t, strings.HasPrefix(paragraphs[0], "The Go team is happy to announce"))
+		assert.True(t, strings.HasPrefix(paragraphs[3], "Thanks to everyone"))
+	})
+
+	t.Run("prefers the article element", func(t *testing.T) {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+			<div><p>Related: ten other stories you might like to read today, or tomorrow, or never.</p></div>
+			<article><h1>Title</h1><p>東京都は16日、新しい防災アプリの配信を始めたと発表しました。</p></article>
+		</body></html>`))
+		require.NoError(t, err)
+
+		assert.Equal(t, "東京都は16日、新しい防災アプリの配信を始めたと発表しました。", extractMainText(doc))
+	})
+}
+
+func TestFetchArticle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.7"))
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html><body><p>" + strings.Repeat("a", 1000) + "</p></body></html>"))
+		}
+	}))
+	defer server.Close()
+
+	t.Run("reads at most maxBytes", func(t *testing.T) {
+		doc, err := fetchArticle(context.Background(), server.Client(), server.URL, 100)
+		require.NoError(t, err)
+		assert.Less(t, len(doc.Find("p").Text()), 100)
+	})
+
+	t.Run("rejects pages that aren't HTML", func(t *testing.T) {
+		_, err := fetchArticle(context.Background(), server.Client(), server.URL+"/pdf", 100)
+		assert.EqualError(t, err, "not an HTML page: application/pdf")
+	})
+
+	t.Run("returns error on non-200 status", func(t *testing.T) {
+		_, err := fetchArticle(context.Background(), server.Client(), server.URL+"/missing", 100)
+		assert.EqualError(t, err, "unexpected status code: 404")
+	})
+}
+
+func TestFetchArticles(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.ServeFile(w, r, "testdata/article.html")
+	}))
+	defer server.Close()
+	summary := &SummaryConfig{Sentences: 1, MaxLength: 60}
+
+	t.Run("summaries alone estimate the reading time", func(t *testing.T) {
+		items := []Item{{Title: "Go 1.27 is released", Link: server.URL + "/article"}}
+
+		fetchArticles(context.Background(), items, nil, summary)
+
+		assert.NotEmpty(t, items[0].Summary)
+		assert.Equal(t, 1, items[0].ReadingMinutes)
+		assert.Zero(t, items[0].OpenGraph)
+	})
+
+	t.Run("enrichment and summaries share one fetch", func(t *testing.T) {
+		requests = 0
+		enrich := &EnrichConfig{CacheDir: t.TempDir()}
+		items := []Item{{Title: "Go 1.27 is released", Link: server.URL + "/article"}}
+
+		fetchArticles(context.Background(), items, enrich, summary)
+
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, "The Go team is happy to announce the release of Go 1.27, wh…", items[0].Summary)
+		assert.Equal(t, 1, items[0].ReadingMinutes)
+
+		cached := []Item{{Title: "Go 1.27 is released", Link: server.URL + "/article"}}
+		fetchArticles(context.Background(), cached, enrich, summary)
+		assert.Equal(t, 1, requests, "the summary comes from the cache too")
+		assert.Equal(t, items, cached)
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileCache keeps JSON values on disk, one file per key named after the key's hash,
+// and treats entries older than the TTL as missing. A nil cache never hits and stores nothing.
+type fileCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+func newFileCache(dir string, ttl time.Duration) *fileCache {
+	return &fileCache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+type fileCacheEntry struct {
+	Key      string          `json:"key"`
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+func (c *fileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// get decodes the value cached under key into v and reports whether there was a fresh one
+func (c *fileCache) get(key string, v any) bool {
+	if c == nil {
+		return false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return false
+	}
+	var entry fileCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return false
+	}
+	if c.now().Sub(entry.StoredAt) > c.ttl {
+		return false
+	}
+	return json.Unmarshal(entry.Value, v) == nil
+}
+
+// put stores v under key, writing through a temporary file so a concurrent
+// run never reads a partial entry
+func (c *fileCache) put(key string, v any) error {
+	if c == nil {
+		return nil
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(fileCacheEntry{Key: key, StoredAt: c.now(), Value: value})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCache(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	cache := &fileCache{dir: t.TempDir(), ttl: time.Hour, now: func() time.Time { return now }}
+	og := OpenGraph{Title: "Cached"}
+
+	require.NoError(t, cache.put("https://example.com/a", og))
+
+	var got OpenGraph
+	assert.True(t, cache.get("https://example.com/a", &got))
+	assert.Equal(t, og, got)
+	assert.False(t, cache.get("https://example.com/b", &got))
+
+	now = now.Add(61 * time.Minute)
+	assert.False(t, cache.get("https://example.com/a", &got), "expired entries are missing")
+
+	var nilCache *fileCache
+	assert.False(t, nilCache.get("https://example.com/a", &got))
+	assert.NoError(t, nilCache.put("https://example.com/a", og))
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that only identify where a click came from
+var trackingParams = map[string]bool{
+	"ref":     true,
+	"ref_src": true,
+	"ref_url": true,
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+	"amp":     true,
+}
+
+// redirectors maps the hosts of link wrappers to the query parameter carrying the target
+var redirectors = map[string]string{
+	"www.google.com":  "url",
+	"google.com":      "url",
+	"l.facebook.com":  "u",
+	"lm.facebook.com": "u",
+	"out.reddit.com":  "url",
+	"slack-redir.net": "url",
+	"www.youtube.com": "q",
+	"l.instagram.com": "u",
+	"t.umblr.com":     "z",
+	"href.li":         "",
+}
+
+// maxRedirectUnwrap bounds how many nested redirectors are unwrapped
+const maxRedirectUnwrap = 3
+
+// canonicalURL normalizes link so the same article reached through different links
+// compares equal: the scheme and host are lowercased, default ports, fragments and
+// tracking parameters are dropped, AMP variants and known redirectors are unwrapped,
+// and trailing slashes are removed. Links that don't parse are returned unchanged.
+func canonicalURL(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return link
+	}
+
+	for range maxRedirectUnwrap {
+		target, ok := unwrapRedirect(u)
+		if !ok {
+			break
+		}
+		u = target
+	}
+	u = unwrapAMP(u)
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		u.Host = u.Hostname()
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	// Encode sorts the parameters, so their order doesn't matter either
+	u.RawQuery = query.Encode()
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String()
+}
+
+// unwrapRedirect returns the target of a link through a known redirector
+func unwrapRedirect(u *url.URL) (*url.URL, bool) {
+	param, ok := redirectors[strings.ToLower(u.Hostname())]
+	if !ok {
+		return nil, false
+	}
+	// Google only redirects through /url; its other pages are real destinations
+	if strings.HasSuffix(u.Hostname(), "google.com") && u.Path != "/url" {
+		return nil, false
+	}
+	if strings.HasSuffix(u.Hostname(), "youtube.com") && u.Path != "/redirect" {
+		return nil, false
+	}
+
+	// href.li puts the whole target after the question mark
+	target := u.RawQuery
+	if param != "" {
+		target = u.Query().Get(param)
+	}
+	t, err := url.Parse(target)
+	if err != nil || (t.Scheme != "http" && t.Scheme != "https") || t.Host == "" {
+		return nil, false
+	}
+	return t, true
+}
+
+// unwrapAMP maps AMP pages and AMP cache links to the article they render. AMP path
+// markers (/amp, /amp/..., .amp) are only removed from links with another AMP signal,
+// so that paths like github.com/ampproject/amp are left alone.
+func unwrapAMP(u *url.URL) *url.URL {
+	host := strings.ToLower(u.Hostname())
+	isAMP := false
+
+	// https://example-com.cdn.ampproject.org/c/s/example.com/article
+	// https://www.google.com/amp/s/example.com/article
+	if strings.HasSuffix(host, ".cdn.ampproject.org") || (host == "www.google.com" && strings.HasPrefix(u.Path, "/amp/")) {
+		path := strings.TrimPrefix(strings.TrimPrefix(u.Path, "/amp"), "/c")
+		scheme := "http"
+		if rest, ok := strings.CutPrefix(path, "/s/"); ok {
+			scheme, path = "https", rest
+		} else {
+			path = strings.TrimPrefix(path, "/")
+		}
+		if t, err := url.Parse(scheme + "://" + path); err == nil && t.Host != "" {
+			t.RawQuery = u.RawQuery
+			u = t
+			isAMP = true
+		}
+	}
+
+	c := *u
+	// amp.example.com, but not amp.dev itself
+	if rest, ok := strings.CutPrefix(c.Host, "amp."); ok && strings.Contains(rest, ".") {
+		c.Host = rest
+		isAMP = true
+	}
+	query := c.Query()
+	if query.Get("outputType") == "amp" {
+		query.Del("outputType")
+		c.RawQuery = query.Encode()
+		isAMP = true
+	}
+	if query.Has("amp") {
+		isAMP = true
+	}
+	if !isAMP {
+		return &c
+	}
+
+	switch {
+	case strings.HasSuffix(c.Path, "/amp") || strings.HasSuffix(c.Path, "/amp/"):
+		c.Path = strings.TrimSuffix(strings.TrimSuffix(c.Path, "/"), "/amp")
+	case strings.HasPrefix(c.Path, "/amp/"):
+		c.Path = strings.TrimPrefix(c.Path, "/amp")
+	case strings.HasSuffix(c.Path, ".amp"):
+		c.Path = strings.TrimSuffix(c.Path, ".amp")
+	}
+	c.RawPath = ""
+	return &c
+}
+
+// dedupeItems removes items linking to an article already in the list, comparing their
+// canonical links; the links themselves are sent as the sources gave them. Of the duplicates,
+// the item from the source with the highest priority is kept, at the position the article
+// first appeared; ties go to the earlier one.
+func dedupeItems(ctx context.Context, items []Item, priority func(source string) int) []Item {
+	kept := make([]Item, 0, len(items))
+	index := make(map[string]int)
+	for _, item := range items {
+		key := canonicalURL(item.Link)
+		if key == "" {
+			kept = append(kept, item)
+			continue
+		}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(kept)
+			kept = append(kept, item)
+			continue
+		}
+
+		recordDropped(ctx, dropDuplicate)
+		if priority(item.Source) > priority(kept[i].Source) {
+			kept[i] = item
+		}
+	}
+	return kept
+}
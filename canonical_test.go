@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{"already canonical", "https://example.com/post/1", "https://example.com/post/1"},
+		{"host case and default port", "HTTPS://Example.COM:443/Post/1", "https://example.com/Post/1"},
+		{"tracking parameters", "https://example.com/post/1?utm_source=hn&utm_medium=rss&ref=hn&id=7", "https://example.com/post/1?id=7"},
+		{"parameter order", "https://example.com/search?q=go&page=2", "https://example.com/search?page=2&q=go"},
+		{"fragment", "https://example.com/post/1#comments", "https://example.com/post/1"},
+		{"trailing slash", "https://example.com/post/1/", "https://example.com/post/1"},
+		{"root", "https://example.com", "https://example.com/"},
+		{"amp path suffix", "https://amp.example.com/post/1/amp/", "https://example.com/post/1"},
+		{"amp path prefix", "https://example.com/amp/post/1?amp", "https://example.com/post/1"},
+		{"amp path without amp signal", "https://github.com/ampproject/amp", "https://github.com/ampproject/amp"},
+		{"amp path prefix without amp signal", "https://example.com/amp/post/1", "https://example.com/amp/post/1"},
+		{"amp subdomain", "https://amp.example.com/post/1?amp=1", "https://example.com/post/1"},
+		{"amp.dev is a real site", "https://amp.dev/about", "https://amp.dev/about"},
+		{"amp output type", "https://news.example.com/a?outputType=amp", "https://news.example.com/a"},
+		{"amp cache", "https://example-com.cdn.ampproject.org/c/s/example.com/post/1", "https://example.com/post/1"},
+		{"google amp viewer", "https://www.google.com/amp/s/example.com/post/1", "https://example.com/post/1"},
+		{"google redirect", "https://www.google.com/url?q=x&url=https%3A%2F%2Fexample.com%2Fpost%2F1%3Futm_source%3Dg", "https://example.com/post/1"},
+		{"google search is not a redirect", "https://www.google.com/search?q=go", "https://www.google.com/search?q=go"},
+		{"facebook redirect", "https://l.facebook.com/l.php?u=https%3A%2F%2Fexample.com%2Fpost%2F1&h=AT0", "https://example.com/post/1"},
+		{"nested redirects", "https://href.li/?https://out.reddit.com/t3_x?url=https%3A%2F%2Fexample.com%2Fpost%2F1", "https://example.com/post/1"},
+		{"redirect to a non-web scheme", "https://l.facebook.com/l.php?u=javascript%3Aalert(1)", "https://l.facebook.com/l.php?u=javascript%3Aalert%281%29"},
+		{"not a URL", "::not a url", "::not a url"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, canonicalURL(tt.link))
+		})
+	}
+}
+
+func TestDedupeItems(t *testing.T) {
+	items := []Item{
+		{Title: "Go 1.27 is released", Link: "https://go.dev/blog/go1.27?utm_source=hnrss", Source: "HN", Score: 912},
+		{Title: "Profiling with pprof", Link: "https://example.com/pprof", Source: "HN"},
+		{Title: "Go 1.27 released", Link: "https://go.dev/blog/go1.27/", Source: "Lobsters"},
+		{Title: "Go 1.27", Link: "https://GO.dev/blog/go1.27#top", Source: "r/golang"},
+		{Title: "Ask HN: no link", Source: "HN"},
+		{Title: "Tell HN: no link either", Source: "HN"},
+	}
+	priorities := map[string]int{"Lobsters": 10}
+
+	ctx, dropped := withDropCounter(context.Background())
+	kept := dedupeItems(ctx, items, func(source string) int { return priorities[source] })
+
+	assert.Equal(t, []Item{
+		{Title: "Go 1.27 released", Link: "https://go.dev/blog/go1.27/", Source: "Lobsters"},
+		{Title: "Profiling with pprof", Link: "https://example.com/pprof", Source: "HN"},
+		{Title: "Ask HN: no link", Source: "HN"},
+		{Title: "Tell HN: no link either", Source: "HN"},
+	}, kept)
+	assert.Equal(t, []DropCount{{Reason: dropDuplicate, Count: 2}}, dropped.list())
+
+	// Without priorities the first source listed wins
+	kept = dedupeItems(context.Background(), items, func(string) int { return 0 })
+	assert.Equal(t, "HN", kept[0].Source)
+	assert.Equal(t, 912, kept[0].Score)
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// invocation carries the top-level flags and output streams to a subcommand,
+// along with the command's run ID and the logger its lines go through
+type invocation struct {
+	configPath string
+	stdout     io.Writer
+	stderr     io.Writer
+
+	command string
+	runID   string
+	logger  *slog.Logger
+}
+
+// command is a subcommand of the imakoko binary
+type command struct {
+	name        string
+	description string
+	run         func(inv *invocation, args []string) error
+}
+
+// commands lists the subcommands; without one, imakoko runs the digest
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "run", description: "fetch the news and send the LINE digest (default)", run: runDigest},
+		{name: "fetch", description: "print the collected items as a table or JSON", run: runFetch},
+		{name: "preview", description: "print the formatted messages without sending", run: runPreview},
+		{name: "send-test", description: "push a single test message to check the credentials", run: runSendTest},
+		{name: "validate", description: "check the config and print the effective settings", run: runValidate},
+		{name: "opml", description: "import or export the feed list as OPML", run: runOPML},
+	}
+}
+
+// runCommand dispatches args to their subcommand and returns the exit code
+func runCommand(inv *invocation, args []string) int {
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		beginRun(inv, cmd.name)
+		if err := cmd.run(inv, args[1:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 2
+			}
+			inv.logger.Error("command failed", "error", err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(inv.stderr, "imakoko: unknown command %q\n\n", args[0])
+	printUsage(inv.stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: imakoko [-config FILE] [command] [flags]")
+	fmt.Fprintln(w, "\nWithout a command, runs the digest. Use imakoko COMMAND -h for command flags.")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.description)
+	}
+}
+
+// configFlags are command-line overrides of Config shared by several commands
+type configFlags struct {
+	rssURL    string
+	target    string
+	dryRun    bool
+	dryRunDir string
+}
+
+func (f *configFlags) registerRSSURL(flags *flag.FlagSet) {
+	flags.StringVar(&f.rssURL, "rss-url", "", "fetch only this feed instead of the configured sources")
+}
+
+func (f *configFlags) registerTarget(flags *flag.FlagSet) {
+	flags.StringVar(&f.target, "target", "", "LINE user, group or room ID to push to (overrides TARGET_USER_ID and targets)")
+}
+
+func (f *configFlags) registerDryRun(flags *flag.FlagSet) {
+	flags.BoolVar(&f.dryRun, "dry-run", false, "record the LINE payloads instead of sending them")
+	flags.StringVar(&f.dryRunDir, "dry-run-dir", "", "write one payload file per batch to this directory (implies -dry-run)")
+}
+
+// load reads the config and applies the overrides given on the command line
+func (f *configFlags) load(inv *invocation) (*Config, error) {
+	cfg, err := readConfig(inv.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if f.rssURL != "" {
+		cfg.RSSURL = f.rssURL
+		cfg.Sources = []SourceConfig{{Type: "rss", URL: f.rssURL}}
+	}
+	// A target given on the command line is the only recipient
+	if f.target != "" {
+		cfg.TargetUserID = f.target
+		cfg.Targets = nil
+	}
+	if f.dryRun || f.dryRunDir != "" {
+		cfg.DryRun = true
+	}
+	if f.dryRunDir != "" {
+		cfg.DryRunDir = f.dryRunDir
+	}
+	return cfg, nil
+}
+
+// runDigest fetches, formats and sends the news digest
+func runDigest(inv *invocation, args []string) error {
+	var overrides configFlags
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(inv.stderr)
+	overrides.registerRSSURL(flags)
+	overrides.registerTarget(flags)
+	overrides.registerDryRun(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := overrides.load(inv)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	ctx, err := startRun(inv, cfg)
+	if err != nil {
+		return err
+	}
+
+	digest, err := fetchDigest(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	sent, err := deliverDigest(ctx, cfg, digest, inv.stdout)
+	if err != nil {
+		return err
+	}
+
+	if cfg.DryRun {
+		loggerFrom(ctx).Info("dry run recorded messages without sending", "messages", sent)
+		return nil
+	}
+	loggerFrom(ctx).Info("sent messages", "messages", sent)
+	return nil
+}
+
+// runFetch prints the collected items without formatting or sending them
+func runFetch(inv *invocation, args []string) error {
+	var overrides configFlags
+	flags := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	flags.SetOutput(inv.stderr)
+	overrides.registerRSSURL(flags)
+	format := flags.String("format", "table", "output format: table or json")
+	explain := flags.Bool("explain", false, "print how every item's ranking score came about")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown format %q, expected table or json", *format)
+	}
+	if *explain && *format != "table" {
+		return errors.New("-explain prints a table and can't be combined with -format json")
+	}
+
+	cfg, err := overrides.load(inv)
+	if err != nil {
+		return err
+	}
+	if err := validateSources(cfg.Sources); err != nil {
+		return err
+	}
+	ctx, err := startRun(inv, cfg)
+	if err != nil {
+		return err
+	}
+
+	if *explain {
+		return explainRanking(ctx, inv.stdout, cfg)
+	}
+
+	items, err := fetchItems(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(inv.stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(items)
+	}
+	return writeItemTable(inv.stdout, items)
+}
+
+// writeItemTable prints one item per row, keeping titles on a single line
+func writeItemTable(w io.Writer, items []Item) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "#\tSOURCE\tTITLE\tLINK")
+	for i, item := range items {
+		title := strings.Join(strings.Fields(item.Title), " ")
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\n", i+1, item.Source, title, item.Link)
+	}
+	return table.Flush()
+}
+
+// explainRanking ranks every collected item, with the default weights when the config has
+// no ranking, and prints the score breakdowns. Items beyond top_n are listed without a rank.
+func explainRanking(ctx context.Context, w io.Writer, cfg *Config) error {
+	var rankingCfg RankingConfig
+	if cfg.Ranking != nil {
+		rankingCfg = *cfg.Ranking
+	}
+	r, err := newRanker(rankingCfg)
+	if err != nil {
+		return err
+	}
+
+	items, err := collectItems(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	return writeScoreTable(w, r.rank(items), rankingCfg.TopN)
+}
+
+// writeScoreTable prints one ranked item per row with the factors of its score
+func writeScoreTable(w io.Writer, ranked []scoreBreakdown, topN int) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "#\tSCORE\tPOINTS\tCOMMENTS/H\tKEYWORDS\tWEIGHT\tAGE\tDECAY\tSOURCE\tTITLE")
+	for i, b := range ranked {
+		rank := strconv.Itoa(i + 1)
+		if topN > 0 && i >= topN {
+			rank = "-"
+		}
+		keywords := "-"
+		if len(b.Keywords) > 0 {
+			keywords = fmt.Sprintf("%+g (%s)", b.KeywordBoost, strings.Join(b.Keywords, ","))
+		}
+		age := "?"
+		if b.AgeHours >= 0 {
+			age = fmt.Sprintf("%.1fh", b.AgeHours)
+		}
+		title := strings.Join(strings.Fields(b.Item.Title), " ")
+		fmt.Fprintf(table, "%s\t%.4f\t%g\t%.1f\t%s\t%g\t%s\t%.4f\t%s\t%s\n",
+			rank, b.Score, b.Points, b.Velocity, keywords, b.SourceWeight, age, b.Decay, b.Item.Source, title)
+	}
+	return table.Flush()
+}
+
+// runPreview prints the messages the digest would send
+func runPreview(inv *invocation, args []string) error {
+	var overrides configFlags
+	flags := flag.NewFlagSet("preview", flag.ContinueOnError)
+	flags.SetOutput(inv.stderr)
+	overrides.registerRSSURL(flags)
+	locale := flags.String("locale", "", "language of the messages (default: that of the first target)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := overrides.load(inv)
+	if err != nil {
+		return err
+	}
+	if err := validateSources(cfg.Sources); err != nil {
+		return err
+	}
+	if *locale == "" {
+		*locale = cfg.locales()[0]
+	}
+	ctx, err := startRun(inv, cfg)
+	if err != nil {
+		return err
+	}
+
+	digest, err := fetchDigest(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	messages, err := formatMessages(cfg, *locale, translateDigest(ctx, cfg, *locale, digest))
+	if err != nil {
+		return err
+	}
+
+	for i, msg := range messages {
+		fmt.Fprintf(inv.stdout, "--- message %d/%d (%d characters) ---\n%s\n", i+1, len(messages), len(msg), msg)
+	}
+	return nil
+}
+
+// runSendTest pushes a single canned message to check the LINE credentials
+func runSendTest(inv *invocation, args []string) error {
+	var overrides configFlags
+	flags := flag.NewFlagSet("send-test", flag.ContinueOnError)
+	flags.SetOutput(inv.stderr)
+	overrides.registerTarget(flags)
+	message := flags.String("message", "", "text of the test message (default: a canned one in each target's locale)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := overrides.load(inv)
+	if err != nil {
+		return err
+	}
+	// Checking the credentials only makes sense against the real API
+	cfg.DryRun = false
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if _, err := startRun(inv, cfg); err != nil {
+		return err
+	}
+
+	targets := cfg.targets()
+	for _, target := range targets {
+		text := *message
+		if text == "" {
+			l, err := NewLocalizer(target.Locale)
+			if err != nil {
+				return err
+			}
+			text = l.T(msgTestMessage)
+		}
+		if err := newLineSender(cfg, target.UserID, len(targets) > 1, inv.stdout).Send([]string{text}); err != nil {
+			return fmt.Errorf("failed to send LINE message to %s: %w", target.UserID, err)
+		}
+		fmt.Fprintf(inv.stdout, "Sent test message to %s\n", target.UserID)
+	}
+	return nil
+}
+
+// runValidate prints the effective config along with every problem it has, failing when there are any
+func runValidate(inv *invocation, args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(inv.stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := readConfig(inv.configPath)
+	if err != nil {
+		return err
+	}
+	// Broken log settings are listed with the other problems instead
+	if cfg.Log.validate() == nil {
+		if _, err := startRun(inv, cfg); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(inv.stdout, cfg.String())
+
+	if err := cfg.Validate(); err != nil {
+		problems := configProblems(err)
+		for i, problem := range problems {
+			problems[i] = strings.ReplaceAll(problem, "\n", "\n    ")
+		}
+		fmt.Fprintf(inv.stdout, "config has %d problem(s):\n  - %s\n", len(problems), strings.Join(problems, "\n  - "))
+		return fmt.Errorf("config has %d problem(s)", len(problems))
+	}
+	fmt.Fprintln(inv.stdout, "config is valid")
+	return nil
+}
+
+// configProblems flattens the joined errors of Validate into one message per problem.
+// A problem's own message may span several lines.
+func configProblems(err error) []string {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []string{err.Error()}
+	}
+	var problems []string
+	for _, err := range joined.Unwrap() {
+		problems = append(problems, configProblems(err)...)
+	}
+	return problems
+}
+
+// runOPML implements `opml import FILE` and `opml export`
+func runOPML(inv *invocation, args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected import or export")
+	}
+
+	switch args[0] {
+	case "import":
+		flags := flag.NewFlagSet("opml import", flag.ContinueOnError)
+		flags.SetOutput(inv.stderr)
+		output := flags.String("o", "", "write the feed list to this file instead of stdout")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return errors.New("usage: opml import [-o feeds.yaml] FILE.opml")
+		}
+
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("failed to open OPML file: %w", err)
+		}
+		defer file.Close()
+
+		sources, err := importOPML(file)
+		if err != nil {
+			return err
+		}
+		return writeOutput(*output, inv.stdout, func(w io.Writer) error {
+			encoder := yaml.NewEncoder(w)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(FeedList{Sources: sources}); err != nil {
+				return fmt.Errorf("failed to write feed list: %w", err)
+			}
+			return encoder.Close()
+		})
+
+	case "export":
+		flags := flag.NewFlagSet("opml export", flag.ContinueOnError)
+		flags.SetOutput(inv.stderr)
+		output := flags.String("o", "", "write the OPML to this file instead of stdout")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		cfg, err := readConfig(inv.configPath)
+		if err != nil {
+			return err
+		}
+		if _, err := startRun(inv, cfg); err != nil {
+			return err
+		}
+		return writeOutput(*output, inv.stdout, func(w io.Writer) error {
+			return exportOPML(w, cfg.Sources, time.Now())
+		})
+
+	default:
+		return fmt.Errorf("unknown opml command %q, expected import or export", args[0])
+	}
+}
+
+// writeOutput runs write against the file at path, or stdout when path is empty
+func writeOutput(path string, stdout io.Writer, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
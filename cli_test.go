@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCommand(t *testing.T) {
+	t.Run("rejects unknown commands with usage", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"frobnicate"})
+
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr.String(), `unknown command "frobnicate"`)
+		assert.Contains(t, stderr.String(), "opml")
+	})
+
+	t.Run("reports command errors with exit code 1", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"opml", "sync"})
+
+		assert.Equal(t, 1, code)
+		assert.Regexp(t, `^time=\S+ level=ERROR msg="command failed" run_id=[0-9a-f]{16} command=opml `+
+			`error="unknown opml command \\"sync\\", expected import or export"\n$`, stderr.String())
+	})
+
+	t.Run("logs command errors as configured", func(t *testing.T) {
+		t.Setenv("LOG_FORMAT", "json")
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"opml", "sync"})
+
+		assert.Equal(t, 1, code)
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(stderr.Bytes(), &line))
+		assert.Equal(t, "ERROR", line["level"])
+		assert.Equal(t, "opml", line["command"])
+		assert.Len(t, line["run_id"], 16)
+		assert.Equal(t, `unknown opml command "sync", expected import or export`, line["error"])
+	})
+}
+
+func TestRunOPML(t *testing.T) {
+	t.Setenv("RSS_URL", "")
+	t.Setenv("REDDIT_SUBREDDITS", "")
+	t.Setenv("GITHUB_REPOS", "")
+	t.Setenv("SCRAPE_URL", "")
+	t.Setenv("FEEDS_FILE", "")
+	t.Setenv("IMAKOKO_CONFIG", "")
+
+	t.Run("import writes a feed list that FEEDS_FILE loads", func(t *testing.T) {
+		feedsPath := filepath.Join(t.TempDir(), "feeds.yaml")
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"opml", "import", "-o", feedsPath, "testdata/feeds.opml"})
+		require.Equal(t, 0, code, stderr.String())
+		assert.Empty(t, stdout.String())
+
+		t.Setenv("FEEDS_FILE", feedsPath)
+		cfg, err := readConfig("")
+		require.NoError(t, err)
+		sources := cfg.Sources
+
+		// The Hacker News feed in the OPML duplicates the default RSS_URL
+		require.Len(t, sources, 4)
+		assert.Equal(t, SourceConfig{Type: "rss", URL: "https://hnrss.org/frontpage"}, sources[0])
+		assert.Equal(t, SourceConfig{Type: "rss", Name: "The Go Blog", URL: "https://go.dev/blog/feed.atom", Labels: []string{"Tech", "Go"}}, sources[1])
+	})
+
+	t.Run("import prints YAML to stdout", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"opml", "import", "testdata/feeds.opml"})
+		require.Equal(t, 0, code, stderr.String())
+		assert.Contains(t, stdout.String(), "sources:\n  - type: rss\n    name: Hacker News\n    url: https://hnrss.org/frontpage\n")
+		assert.Contains(t, stdout.String(), "    labels:\n      - Tech\n      - Go\n")
+	})
+
+	t.Run("import requires a file", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"opml", "import"})
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr.String(), "usage: opml import")
+	})
+
+	t.Run("export writes the current feed list", func(t *testing.T) {
+		feedsPath := filepath.Join(t.TempDir(), "feeds.yaml")
+		require.NoError(t, os.WriteFile(feedsPath, []byte("sources:\n  - type: rss\n    name: Lobsters\n    url: https://lobste.rs/rss\n    labels: [Tech]\n"), 0o600))
+		t.Setenv("FEEDS_FILE", feedsPath)
+		t.Setenv("REDDIT_SUBREDDITS", "golang")
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"opml", "export"})
+		require.Equal(t, 0, code, stderr.String())
+
+		sources, err := importOPML(&stdout)
+		require.NoError(t, err)
+		assert.Equal(t, []SourceConfig{
+			{Type: "rss", Name: "https://hnrss.org/frontpage", URL: "https://hnrss.org/frontpage"},
+			{Type: "rss", Name: "Lobsters", URL: "https://lobste.rs/rss", Labels: []string{"Tech"}},
+		}, sources)
+	})
+}
+
+func TestRunValidate(t *testing.T) {
+	clearConfigEnv(t)
+
+	t.Run("prints the effective config and succeeds", func(t *testing.T) {
+		t.Setenv("LINE_ACCESS_TOKEN", "token12345")
+		t.Setenv("TARGET_USER_ID", testUserID)
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"validate"})
+
+		assert.Equal(t, 0, code, stderr.String())
+		assert.Contains(t, stdout.String(), `LineAccessToken: "to***45"`)
+		assert.NotContains(t, stdout.String(), "token12345")
+		assert.Contains(t, stdout.String(), "config is valid")
+	})
+
+	t.Run("lists every problem and exits non-zero", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), "imakoko.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte(`target_user_id: bob
+line_api_url: api.line.me/push
+sources:
+  - type: reddit
+  - type: rss
+    url: ftp://example.com/feed
+`), 0o600))
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{configPath: configPath, stdout: &stdout, stderr: &stderr}, []string{"validate"})
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stdout.String(), `TargetUserID: "bob"`)
+		assert.True(t, strings.HasSuffix(stdout.String(), `config has 5 problem(s):
+  - LINE_ACCESS_TOKEN environment variable is required
+  - TARGET_USER_ID "bob" is not a LINE user, group or room ID
+  - LINE_API_URL "api.line.me/push" must be an absolute http(s) URL
+  - source 1 (reddit): reddit source requires a subreddit
+  - source 2 (rss): URL "ftp://example.com/feed" must be an absolute http(s) URL
+`), stdout.String())
+		assert.Contains(t, stderr.String(), `msg="command failed" run_id=`)
+		assert.Contains(t, stderr.String(), `command=validate error="config has 5 problem(s)"`)
+	})
+}
+
+// newDigestServers starts a feed server and a fake LINE API recording pushed payloads
+func newDigestServers(t *testing.T) (feed *httptest.Server, line *httptest.Server, pushed *[]LineMessages) {
+	feed = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testRSS))
+	}))
+	t.Cleanup(feed.Close)
+
+	pushed = &[]LineMessages{}
+	line = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token12345", r.Header.Get("Authorization"))
+		var payload LineMessages
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		*pushed = append(*pushed, payload)
+		w.Write([]byte(`{"sentMessages": []}`))
+	}))
+	t.Cleanup(line.Close)
+	return feed, line, pushed
+}
+
+// clearConfigEnv isolates a test from configuration in the environment
+func clearConfigEnv(t *testing.T) {
+	for _, key := range []string{"LINE_ACCESS_TOKEN", "LINE_ACCESS_TOKEN_FILE", "TARGET_USER_ID", "LINE_API_URL", "RSS_URL",
+		"IMAKOKO_CONFIG", "REDDIT_SUBREDDITS", "GITHUB_REPOS", "SCRAPE_URL", "FEEDS_FILE", "DRY_RUN", "DRY_RUN_DIR",
+		"TIMEZONE", "LOCALE", "LOG_FORMAT", "LOG_LEVEL"} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestConfigProblems(t *testing.T) {
+	err := errors.Join(
+		errors.New("LINE_ACCESS_TOKEN environment variable is required"),
+		errors.Join(errors.New("source 1 (reddit): reddit source requires a subreddit")),
+		fmt.Errorf("format: %w", errors.New("template: item:1: unexpected \"}\"\nin operand")),
+	)
+
+	assert.Equal(t, []string{
+		"LINE_ACCESS_TOKEN environment variable is required",
+		"source 1 (reddit): reddit source requires a subreddit",
+		"format: template: item:1: unexpected \"}\"\nin operand",
+	}, configProblems(err))
+}
+
+func TestRunDigest(t *testing.T) {
+	clearConfigEnv(t)
+	feed, line, pushed := newDigestServers(t)
+	t.Setenv("LINE_ACCESS_TOKEN", "token12345")
+	t.Setenv("TARGET_USER_ID", testUserID)
+	t.Setenv("LINE_API_URL", line.URL)
+	t.Setenv("RSS_URL", feed.URL)
+
+	t.Run("sends the digest to the configured target", func(t *testing.T) {
+		*pushed = nil
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"run"})
+
+		require.Equal(t, 0, code, stderr.String())
+		require.Len(t, *pushed, 1)
+		assert.Equal(t, testUserID, (*pushed)[0].SendTo)
+		assert.Equal(t, []LineContent{
+			{Type: "text", Text: "1. First Story\nhttps://example.com/first"},
+			{Type: "text", Text: "2. Second Story\nhttps://example.com/second"},
+		}, (*pushed)[0].Messages)
+	})
+
+	t.Run("flags override the config", func(t *testing.T) {
+		*pushed = nil
+		var stdout, stderr bytes.Buffer
+		otherTarget := "C0123456789abcdef0123456789abcdef"
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"run", "-target", otherTarget, "-rss-url", feed.URL + "/other"})
+
+		require.Equal(t, 0, code, stderr.String())
+		require.Len(t, *pushed, 1)
+		assert.Equal(t, otherTarget, (*pushed)[0].SendTo)
+	})
+
+	t.Run("logs JSON lines sharing the run ID", func(t *testing.T) {
+		t.Setenv("LOG_FORMAT", "json")
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"run"})
+
+		require.Equal(t, 0, code, stderr.String())
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		require.Len(t, lines, 2)
+		var delivered, done map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &delivered))
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &done))
+		assert.Equal(t, "delivered digest", delivered["msg"])
+		assert.Equal(t, testUserID, delivered["target"])
+		assert.Equal(t, "run", delivered["command"])
+		assert.NotEmpty(t, delivered["run_id"])
+		assert.Equal(t, delivered["run_id"], done["run_id"])
+		assert.Equal(t, float64(2), done["messages"])
+	})
+
+	t.Run("fails on invalid config", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"run", "-target", "nobody"})
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr.String(), `TARGET_USER_ID \"nobody\" is not a LINE user, group or room ID`)
+	})
+}
+
+func TestRunFetch(t *testing.T) {
+	clearConfigEnv(t)
+	feed, _, _ := newDigestServers(t)
+
+	t.Run("prints a table without LINE credentials", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"fetch", "-rss-url", feed.URL})
+
+		require.Equal(t, 0, code, stderr.String())
+		host := strings.TrimPrefix(feed.URL, "http://")
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.Regexp(t, `^#\s+SOURCE\s+TITLE\s+LINK$`, lines[0])
+		assert.Regexp(t, `^1\s+`+regexp.QuoteMeta(host)+`\s+First Story\s+https://example.com/first$`, lines[1])
+	})
+
+	t.Run("prints JSON", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"fetch", "-rss-url", feed.URL, "-format", "json"})
+
+		require.Equal(t, 0, code, stderr.String())
+		var items []Item
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &items))
+		require.Len(t, items, 2)
+		assert.Equal(t, "Second Story", items[1].Title)
+		assert.NotContains(t, stdout.String(), "published")
+	})
+
+	t.Run("explains the ranking", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), "imakoko.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte(`
+rss_url: `+feed.URL+`
+ranking:
+  top_n: 1
+  keywords:
+    second: 5
+`), 0o600))
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{configPath: configPath, stdout: &stdout, stderr: &stderr}, []string{"fetch", "-explain"})
+
+		require.Equal(t, 0, code, stderr.String())
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.Regexp(t, `^#\s+SCORE\s+POINTS\s+COMMENTS/H\s+KEYWORDS\s+WEIGHT\s+AGE\s+DECAY\s+SOURCE\s+TITLE$`, lines[0])
+		assert.Regexp(t, `^1\s+1\.7230\s+0\s+0\.0\s+\+5 \(second\)\s+1\s+\?\s+0\.2872\s+\S+\s+Second Story$`, lines[1])
+		assert.Regexp(t, `^-\s+0\.2872\s+0\s+0\.0\s+-\s+1\s+\?\s+0\.2872\s+\S+\s+First Story$`, lines[2])
+	})
+
+	t.Run("rejects -explain with JSON", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"fetch", "-explain", "-format", "json"})
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr.String(), "-explain prints a table")
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"fetch", "-format", "xml"})
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr.String(), `unknown format \"xml\"`)
+	})
+}
+
+func TestRunPreview(t *testing.T) {
+	clearConfigEnv(t)
+	feed, _, pushed := newDigestServers(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"preview", "-rss-url", feed.URL})
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "--- message 1/2 (40 characters) ---\n1. First Story\nhttps://example.com/first\n"+
+		"--- message 2/2 (42 characters) ---\n2. Second Story\nhttps://example.com/second\n", stdout.String())
+	assert.Empty(t, *pushed)
+}
+
+func TestRunSendTest(t *testing.T) {
+	clearConfigEnv(t)
+	_, line, pushed := newDigestServers(t)
+	t.Setenv("LINE_ACCESS_TOKEN", "token12345")
+	t.Setenv("LINE_API_URL", line.URL)
+
+	var stdout, stderr bytes.Buffer
+
+	code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"send-test", "-target", testUserID, "-message", "hello"})
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "Sent test message to "+testUserID+"\n", stdout.String())
+	assert.Equal(t, []LineMessages{{SendTo: testUserID, Messages: []LineContent{{Type: "text", Text: "hello"}}}}, *pushed)
+}
+
+func TestRunDigest_DryRun(t *testing.T) {
+	clearConfigEnv(t)
+	feed, line, pushed := newDigestServers(t)
+	t.Setenv("TARGET_USER_ID", testUserID)
+	t.Setenv("LINE_API_URL", line.URL)
+	t.Setenv("RSS_URL", feed.URL)
+
+	t.Run("prints payloads without a token or API call", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"run", "-dry-run"})
+
+		require.Equal(t, 0, code, stderr.String())
+		assert.Empty(t, *pushed)
+		var payload LineMessages
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &payload))
+		assert.Equal(t, testUserID, payload.SendTo)
+		assert.Len(t, payload.Messages, 2)
+	})
+
+	t.Run("DRY_RUN_DIR from the environment", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("DRY_RUN", "true")
+		t.Setenv("DRY_RUN_DIR", dir)
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{stdout: &stdout, stderr: &stderr}, []string{"run"})
+
+		require.Equal(t, 0, code, stderr.String())
+		assert.Empty(t, stdout.String())
+		assert.FileExists(t, filepath.Join(dir, "batch-001.json"))
+		assert.Empty(t, *pushed)
+	})
+}
+
+func TestRunDigest_Targets(t *testing.T) {
+	clearConfigEnv(t)
+	feed, line, pushed := newDigestServers(t)
+	const groupID = "C0123456789abcdef0123456789abcdef"
+
+	configPath := filepath.Join(t.TempDir(), "imakoko.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+line_access_token: token12345
+line_api_url: `+line.URL+`
+rss_url: `+feed.URL+`
+target_user_id: `+testUserID+`
+targets:
+  - user_id: `+groupID+`
+    locale: en
+format:
+  summary_header: true
+`), 0o600))
+
+	t.Run("formats the digest in each target's locale", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{configPath: configPath, stdout: &stdout, stderr: &stderr}, []string{"run"})
+
+		require.Equal(t, 0, code, stderr.String())
+		require.Len(t, *pushed, 2)
+		assert.Equal(t, testUserID, (*pushed)[0].SendTo)
+		assert.Contains(t, (*pushed)[0].Messages[0].Text, "のニュース: 2件")
+		assert.Equal(t, groupID, (*pushed)[1].SendTo)
+		assert.Contains(t, (*pushed)[1].Messages[0].Text, "news for")
+	})
+
+	t.Run("writes each target's payloads to its own directory", func(t *testing.T) {
+		dir := t.TempDir()
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{configPath: configPath, stdout: &stdout, stderr: &stderr}, []string{"run", "-dry-run-dir", dir})
+
+		require.Equal(t, 0, code, stderr.String())
+		assert.FileExists(t, filepath.Join(dir, testUserID, "batch-001.json"))
+		assert.FileExists(t, filepath.Join(dir, groupID, "batch-001.json"))
+	})
+
+	t.Run("send-test uses the canned message of each locale", func(t *testing.T) {
+		*pushed = nil
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{configPath: configPath, stdout: &stdout, stderr: &stderr}, []string{"send-test"})
+
+		require.Equal(t, 0, code, stderr.String())
+		require.Len(t, *pushed, 2)
+		assert.Equal(t, "imakoko: テストメッセージです", (*pushed)[0].Messages[0].Text)
+		assert.Equal(t, "imakoko: test message", (*pushed)[1].Messages[0].Text)
+	})
+
+	t.Run("-target replaces every configured target", func(t *testing.T) {
+		*pushed = nil
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(&invocation{configPath: configPath, stdout: &stdout, stderr: &stderr}, []string{"send-test", "-target", groupID})
+
+		require.Equal(t, 0, code, stderr.String())
+		require.Len(t, *pushed, 1)
+		assert.Equal(t, groupID, (*pushed)[0].SendTo)
+		assert.Equal(t, "imakoko: テストメッセージです", (*pushed)[0].Messages[0].Text)
+	})
+}
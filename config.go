@@ -1,57 +1,536 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultLineAPIURL = "https://api.line.me/v2/bot/message/push"
+	defaultRSSURL     = "https://hnrss.org/frontpage"
 )
 
 // Config holds application settings
 type Config struct {
-	LineAccessToken string
-	TargetUserID    string
-	LineAPIURL      string
-	RSSURL          string
+	LineAccessToken string `yaml:"line_access_token"`
+	TargetUserID    string `yaml:"target_user_id"`
+	// Targets lists further recipients, each with its own locale
+	Targets []TargetConfig `yaml:"targets"`
+	// Locale is the language of the messages for targets that don't set one
+	Locale string `yaml:"locale"`
+	// Languages keeps only items detected in these languages; empty keeps all
+	Languages  []string       `yaml:"languages"`
+	LineAPIURL string         `yaml:"line_api_url"`
+	RSSURL     string         `yaml:"rss_url"`
+	Sources    []SourceConfig `yaml:"sources"`
+
+	// DryRun records the LINE payloads instead of sending them,
+	// one file per batch in DryRunDir or on stdout when it is empty
+	DryRun    bool   `yaml:"dry_run"`
+	DryRunDir string `yaml:"dry_run_dir"`
+
+	// Timezone is the IANA zone the digest date is shown in, the local zone when empty
+	Timezone string       `yaml:"timezone"`
+	Format   FormatConfig `yaml:"format"`
+	Dedupe   DedupeConfig `yaml:"dedupe"`
+	// Ranking orders the items by score and keeps the top ones; without it items
+	// are sent in the order the sources list them
+	Ranking *RankingConfig `yaml:"ranking"`
+	// Summary fetches the linked articles and adds a short extract to each item
+	Summary *SummaryConfig `yaml:"summary"`
+	// Enrich fetches the Open Graph title, description, image and site name of the linked pages
+	Enrich *EnrichConfig `yaml:"enrich"`
+	// Translation translates English titles for the targets reading its target locale
+	Translation *TranslationConfig `yaml:"translation"`
+	// PaywallDomains lists sites behind a hard paywall; their items get a 🔒 marker.
+	// Subdomains match too.
+	PaywallDomains []string `yaml:"paywall_domains"`
+	// LinkCheck drops or annotates items whose link is dead
+	LinkCheck *LinkCheckConfig `yaml:"link_check"`
+	Log       LogConfig        `yaml:"log"`
+}
+
+// DedupeConfig tunes how stories covered by several sources are merged
+type DedupeConfig struct {
+	// Similarity is the title similarity, from 0 to 1, from which two items count as
+	// the same story; 0 means the default and 1 only merges titles with the same words
+	Similarity float64 `yaml:"similarity"`
+}
+
+// similarity returns the configured threshold or the default
+func (c DedupeConfig) similarity() float64 {
+	if c.Similarity == 0 {
+		return defaultSimilarity
+	}
+	return c.Similarity
+}
+
+// TargetConfig is one LINE user, group or room the digest is pushed to
+type TargetConfig struct {
+	UserID string `yaml:"user_id"`
+	Locale string `yaml:"locale,omitempty"`
+	// Languages further narrows the items this target gets by detected language
+	Languages []string `yaml:"languages,omitempty"`
 }
 
-// LoadConfig reads configuration from environment variables
-func LoadConfig() (*Config, error) {
-	// Required environment variables
-	accessToken := os.Getenv("LINE_ACCESS_TOKEN")
-	if accessToken == "" {
-		return nil, errors.New("LINE_ACCESS_TOKEN environment variable is required")
+// LoadConfig reads the YAML config file at path, or IMAKOKO_CONFIG when path is empty.
+// Environment variables override individual fields, so secrets can stay out of the file.
+func LoadConfig(path string) (*Config, error) {
+	cfg, err := readConfig(path)
+	if err != nil {
+		return nil, err
 	}
 
-	targetUserID := os.Getenv("TARGET_USER_ID")
-	if targetUserID == "" {
-		return nil, errors.New("TARGET_USER_ID environment variable is required")
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
+	return cfg, nil
+}
+
+// lineIDPattern matches LINE user, group and room IDs
+var lineIDPattern = regexp.MustCompile(`^[UCR][0-9a-f]{32}$`)
 
-	// Optional environment variables with defaults
-	apiURL := os.Getenv("LINE_API_URL")
-	if apiURL == "" {
-		apiURL = "https://api.line.me/v2/bot/message/push"
+// Validate checks every setting and reports all problems at once
+func (c *Config) Validate() error {
+	var errs []error
+
+	// Required settings; a dry run never calls the API so it needs no token
+	if c.LineAccessToken == "" && !c.DryRun {
+		errs = append(errs, errors.New("LINE_ACCESS_TOKEN environment variable is required"))
+	}
+	if c.TargetUserID == "" && len(c.Targets) == 0 {
+		errs = append(errs, errors.New("TARGET_USER_ID environment variable is required"))
+	} else if c.TargetUserID != "" && !lineIDPattern.MatchString(c.TargetUserID) {
+		errs = append(errs, fmt.Errorf("TARGET_USER_ID %q is not a LINE user, group or room ID", c.TargetUserID))
+	}
+	for i, target := range c.Targets {
+		if !lineIDPattern.MatchString(target.UserID) {
+			errs = append(errs, fmt.Errorf("targets[%d]: user_id %q is not a LINE user, group or room ID", i, target.UserID))
+		}
+		if err := validateLocale(target.Locale); err != nil {
+			errs = append(errs, fmt.Errorf("targets[%d]: %w", i, err))
+		}
+		if err := validateLanguages(target.Languages); err != nil {
+			errs = append(errs, fmt.Errorf("targets[%d]: %w", i, err))
+		}
+	}
+	if err := validateLocale(c.Locale); err != nil {
+		errs = append(errs, fmt.Errorf("LOCALE: %w", err))
+	}
+	if err := validateLanguages(c.Languages); err != nil {
+		errs = append(errs, fmt.Errorf("languages: %w", err))
+	}
+
+	if !isHTTPURL(c.LineAPIURL) {
+		errs = append(errs, fmt.Errorf("LINE_API_URL %q must be an absolute http(s) URL", c.LineAPIURL))
+	}
+	if c.RSSURL != "" && !isHTTPURL(c.RSSURL) {
+		errs = append(errs, fmt.Errorf("RSS_URL %q must be an absolute http(s) URL", c.RSSURL))
+	}
+
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("TIMEZONE %q is not a known time zone", c.Timezone))
+	}
+
+	if c.Dedupe.Similarity < 0 || c.Dedupe.Similarity > 1 {
+		errs = append(errs, fmt.Errorf("dedupe.similarity %g must be between 0 and 1", c.Dedupe.Similarity))
+	}
+
+	if c.Ranking != nil {
+		errs = append(errs, c.Ranking.validate())
+	}
+	if c.Summary != nil {
+		errs = append(errs, c.Summary.validate())
+	}
+	if c.Enrich != nil {
+		errs = append(errs, c.Enrich.validate())
+	}
+	if c.Translation != nil {
+		errs = append(errs, c.Translation.validate())
+	}
+	if c.LinkCheck != nil {
+		errs = append(errs, c.LinkCheck.validate())
+	}
+	errs = append(errs, c.Log.validate())
+
+	errs = append(errs, validateSources(c.Sources))
+
+	// Templates and grouping are checked in each locale they will render in
+	for _, locale := range c.locales() {
+		l, err := NewLocalizer(locale)
+		if err != nil {
+			continue // reported above
+		}
+		if _, err := newFormatter(c, l); err != nil {
+			errs = append(errs, fmt.Errorf("format: %w", err))
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// targets lists every recipient with its effective locale: TARGET_USER_ID first,
+// then the configured targets, each user ID only once
+func (c *Config) targets() []TargetConfig {
+	var targets []TargetConfig
+	seen := make(map[string]bool)
+	add := func(target TargetConfig) {
+		if target.UserID == "" || seen[target.UserID] {
+			return
+		}
+		seen[target.UserID] = true
+		if target.Locale == "" {
+			target.Locale = c.Locale
+		}
+		if target.Locale == "" {
+			target.Locale = defaultLocale
+		}
+		targets = append(targets, target)
+	}
+
+	add(TargetConfig{UserID: c.TargetUserID})
+	for _, target := range c.Targets {
+		add(target)
+	}
+	return targets
+}
+
+// locales lists the distinct locales the targets read, the default locale when there are none
+func (c *Config) locales() []string {
+	var locales []string
+	for _, target := range c.targets() {
+		if !slices.Contains(locales, target.Locale) {
+			locales = append(locales, target.Locale)
+		}
+	}
+	if len(locales) == 0 {
+		locale := c.Locale
+		if locale == "" {
+			locale = defaultLocale
+		}
+		locales = append(locales, locale)
+	}
+	return locales
+}
+
+// location returns the time zone of the digest date, falling back to the local zone
+func (c *Config) location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// isHTTPURL reports whether s is an absolute http or https URL with a host
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// readConfig merges the config file, environment variables and defaults without
+// checking required settings, for commands that only need part of the config
+func readConfig(path string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv("IMAKOKO_CONFIG")
+	}
+
+	cfg := &Config{}
+	if path != "" {
+		if err := loadConfigFile(path, cfg); err != nil {
+			return nil, err
+		}
+		if err := resolveConfigSecrets(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	// Environment variables take precedence over the file
+	token, ok, err := secretFromEnv("LINE_ACCESS_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cfg.LineAccessToken = token
+	} else if cfg.LineAccessToken, err = resolveSecret(cfg.LineAccessToken); err != nil {
+		return nil, fmt.Errorf("line_access_token: %w", err)
+	}
+	overrideFromEnv(&cfg.TargetUserID, "TARGET_USER_ID")
+	overrideFromEnv(&cfg.LineAPIURL, "LINE_API_URL")
+	overrideFromEnv(&cfg.RSSURL, "RSS_URL")
+	overrideFromEnv(&cfg.DryRunDir, "DRY_RUN_DIR")
+	overrideFromEnv(&cfg.Timezone, "TIMEZONE")
+	overrideFromEnv(&cfg.Locale, "LOCALE")
+	overrideFromEnv(&cfg.Log.Format, "LOG_FORMAT")
+	overrideFromEnv(&cfg.Log.Level, "LOG_LEVEL")
+	if v := os.Getenv("DRY_RUN"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("DRY_RUN must be a boolean: %w", err)
+		}
+		cfg.DryRun = dryRun
+	}
+
+	// Optional settings with defaults; the Hacker News feed is only the default
+	// when the file doesn't list sources of its own
+	if cfg.LineAPIURL == "" {
+		cfg.LineAPIURL = defaultLineAPIURL
+	}
+	if cfg.RSSURL == "" && len(cfg.Sources) == 0 {
+		cfg.RSSURL = defaultRSSURL
 	}
 
-	rssURL := os.Getenv("RSS_URL")
-	if rssURL == "" {
-		rssURL = "https://hnrss.org/frontpage"
+	var sources []SourceConfig
+	if cfg.RSSURL != "" {
+		sources = append(sources, SourceConfig{Type: "rss", URL: cfg.RSSURL})
 	}
+	sources = appendNewSources(sources, cfg.Sources...)
 
-	return &Config{
-		LineAccessToken: accessToken,
-		TargetUserID:    targetUserID,
-		LineAPIURL:      apiURL,
-		RSSURL:          rssURL,
+	envSources, err := loadEnvSources()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Sources = appendNewSources(sources, envSources...)
+
+	return cfg, nil
+}
+
+// loadConfigFile decodes the YAML file at path into cfg, rejecting unknown keys
+func loadConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// resolveConfigSecrets replaces file:// and env:// references in the source tokens and
+// the translation API key of cfg.
+// line_access_token is resolved separately since the environment may override it.
+func resolveConfigSecrets(cfg *Config) error {
+	for i := range cfg.Sources {
+		token, err := resolveSecret(cfg.Sources[i].Token)
+		if err != nil {
+			return fmt.Errorf("sources[%d].token: %w", i, err)
+		}
+		cfg.Sources[i].Token = token
+	}
+	if cfg.Translation != nil {
+		key, err := resolveSecret(cfg.Translation.APIKey)
+		if err != nil {
+			return fmt.Errorf("translation.api_key: %w", err)
+		}
+		cfg.Translation.APIKey = key
+	}
+	return nil
+}
+
+// overrideFromEnv replaces *field with the environment variable when it is set
+func overrideFromEnv(field *string, key string) {
+	if v := os.Getenv(key); v != "" {
+		*field = v
+	}
+}
+
+// loadEnvSources builds the sources configured through environment variables and FEEDS_FILE
+func loadEnvSources() ([]SourceConfig, error) {
+	redditSources, err := loadRedditSources()
+	if err != nil {
+		return nil, err
+	}
+	sources := redditSources
+
+	githubSource, err := loadGitHubSource()
+	if err != nil {
+		return nil, err
+	}
+	if githubSource != nil {
+		sources = append(sources, *githubSource)
+	}
+
+	if scrapeURL := os.Getenv("SCRAPE_URL"); scrapeURL != "" {
+		sources = append(sources, SourceConfig{
+			Type:          "scrape",
+			URL:           scrapeURL,
+			ItemSelector:  os.Getenv("SCRAPE_ITEM_SELECTOR"),
+			TitleSelector: os.Getenv("SCRAPE_TITLE_SELECTOR"),
+			LinkSelector:  os.Getenv("SCRAPE_LINK_SELECTOR"),
+		})
+	}
+
+	if path := os.Getenv("FEEDS_FILE"); path != "" {
+		feeds, err := loadFeedsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, feeds...)
+	}
+
+	return sources, nil
+}
+
+// FeedList is the feed configuration file written by `opml import` and read from FEEDS_FILE
+type FeedList struct {
+	Sources []SourceConfig `yaml:"sources"`
+}
+
+// loadFeedsFile reads a YAML feed list
+func loadFeedsFile(path string) ([]SourceConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feeds file: %w", err)
+	}
+
+	var list FeedList
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&list); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse feeds file %s: %w", path, err)
+	}
+	return list.Sources, nil
+}
+
+// appendNewSources appends sources whose type and URL aren't configured yet,
+// so the same feed listed in several places is only fetched once
+func appendNewSources(sources []SourceConfig, more ...SourceConfig) []SourceConfig {
+	for _, candidate := range more {
+		duplicate := false
+		for _, existing := range sources {
+			if candidate.URL != "" && existing.Type == candidate.Type && existing.URL == candidate.URL {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			sources = append(sources, candidate)
+		}
+	}
+	return sources
+}
+
+// loadRedditSources builds one reddit source per subreddit listed in REDDIT_SUBREDDITS
+func loadRedditSources() ([]SourceConfig, error) {
+	subreddits := splitList(os.Getenv("REDDIT_SUBREDDITS"))
+	if len(subreddits) == 0 {
+		return nil, nil
+	}
+
+	minScore := 0
+	if v := os.Getenv("REDDIT_MIN_SCORE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("REDDIT_MIN_SCORE must be an integer: %w", err)
+		}
+		minScore = n
+	}
+
+	sources := make([]SourceConfig, len(subreddits))
+	for i, subreddit := range subreddits {
+		sources[i] = SourceConfig{
+			Type:      "reddit",
+			Subreddit: subreddit,
+			UserAgent: os.Getenv("REDDIT_USER_AGENT"),
+			MinScore:  minScore,
+		}
+	}
+	return sources, nil
+}
+
+// loadGitHubSource builds a releases source for the repositories listed in GITHUB_REPOS
+func loadGitHubSource() (*SourceConfig, error) {
+	repos := splitList(os.Getenv("GITHUB_REPOS"))
+	if len(repos) == 0 {
+		return nil, nil
+	}
+
+	includePrereleases := false
+	if v := os.Getenv("GITHUB_INCLUDE_PRERELEASES"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("GITHUB_INCLUDE_PRERELEASES must be a boolean: %w", err)
+		}
+		includePrereleases = b
+	}
+
+	token, _, err := secretFromEnv("GITHUB_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+
+	return &SourceConfig{
+		Type:               "github",
+		URL:                os.Getenv("GITHUB_API_URL"),
+		Repos:              repos,
+		Token:              token,
+		IncludePrereleases: includePrereleases,
 	}, nil
 }
 
-// String returns the string representation of the config (token is masked)
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// String returns the string representation of the config (secrets are masked)
 func (c *Config) String() string {
-	maskedToken := "***"
-	if len(c.LineAccessToken) > 4 {
-		maskedToken = c.LineAccessToken[:2] + "***" + c.LineAccessToken[len(c.LineAccessToken)-2:]
+	sources := make([]string, len(c.Sources))
+	for i, source := range c.Sources {
+		sources[i] = source.String()
+	}
+	targets := make([]string, len(c.Targets))
+	for i, target := range c.Targets {
+		targets[i] = fmt.Sprintf("%s(%s)", target.UserID, target.Locale)
+	}
+	return fmt.Sprintf("Config{LineAPIURL: %q, TargetUserID: %q, Targets: [%s], Locale: %q, RSSURL: %q, LineAccessToken: %q, Sources: [%s], DryRun: %t, DryRunDir: %q, Timezone: %q}",
+		c.LineAPIURL, c.TargetUserID, strings.Join(targets, ", "), c.Locale, c.RSSURL, maskSecret(c.LineAccessToken), strings.Join(sources, ", "), c.DryRun, c.DryRunDir, c.Timezone)
+}
+
+// LogValue implements slog.LogValuer, so logging the config never leaks a secret
+func (c *Config) LogValue() slog.Value {
+	targets := make([]string, 0, len(c.Targets))
+	for _, target := range c.Targets {
+		targets = append(targets, target.UserID)
+	}
+	sources := make([]string, 0, len(c.Sources))
+	for _, source := range c.Sources {
+		sources = append(sources, source.String())
+	}
+	attrs := []slog.Attr{
+		slog.String("line_access_token", maskSecret(c.LineAccessToken)),
+		slog.String("line_api_url", c.LineAPIURL),
+		slog.String("target_user_id", c.TargetUserID),
+		slog.String("targets", strings.Join(targets, ",")),
+		slog.String("locale", c.Locale),
+		slog.String("sources", strings.Join(sources, ", ")),
+		slog.Bool("dry_run", c.DryRun),
+		slog.String("timezone", c.Timezone),
+	}
+	if c.Translation != nil {
+		attrs = append(attrs, slog.String("translation_api_key", maskSecret(c.Translation.APIKey)))
 	}
-	return fmt.Sprintf("Config{LineAPIURL: %q, TargetUserID: %q, RSSURL: %q, LineAccessToken: %q}",
-		c.LineAPIURL, c.TargetUserID, c.RSSURL, maskedToken)
+	return slog.GroupValue(attrs...)
 }
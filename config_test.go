@@ -2,12 +2,17 @@ package main
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// testUserID is a well-formed LINE user ID
+const testUserID = "U0123456789abcdef0123456789abcdef"
+
 func TestLoadConfig(t *testing.T) {
 	// Helper to clear and restore environment variables
 	clearEnv := func() {
@@ -15,15 +20,30 @@ func TestLoadConfig(t *testing.T) {
 		os.Unsetenv("TARGET_USER_ID")
 		os.Unsetenv("LINE_API_URL")
 		os.Unsetenv("RSS_URL")
+		os.Unsetenv("REDDIT_SUBREDDITS")
+		os.Unsetenv("REDDIT_USER_AGENT")
+		os.Unsetenv("REDDIT_MIN_SCORE")
+		os.Unsetenv("GITHUB_REPOS")
+		os.Unsetenv("GITHUB_TOKEN")
+		os.Unsetenv("GITHUB_API_URL")
+		os.Unsetenv("GITHUB_INCLUDE_PRERELEASES")
+		os.Unsetenv("SCRAPE_URL")
+		os.Unsetenv("SCRAPE_ITEM_SELECTOR")
+		os.Unsetenv("SCRAPE_TITLE_SELECTOR")
+		os.Unsetenv("SCRAPE_LINK_SELECTOR")
+		os.Unsetenv("FEEDS_FILE")
+		os.Unsetenv("IMAKOKO_CONFIG")
+		os.Unsetenv("LINE_ACCESS_TOKEN_FILE")
+		os.Unsetenv("GITHUB_TOKEN_FILE")
 	}
 
 	t.Run("returns error when LINE_ACCESS_TOKEN is missing", func(t *testing.T) {
 		clearEnv()
 		defer clearEnv()
 
-		os.Setenv("TARGET_USER_ID", "user123")
+		os.Setenv("TARGET_USER_ID", testUserID)
 
-		cfg, err := LoadConfig()
+		cfg, err := LoadConfig("")
 		assert.Nil(t, cfg)
 		assert.EqualError(t, err, "LINE_ACCESS_TOKEN environment variable is required")
 	})
@@ -34,7 +54,7 @@ func TestLoadConfig(t *testing.T) {
 
 		os.Setenv("LINE_ACCESS_TOKEN", "token123")
 
-		cfg, err := LoadConfig()
+		cfg, err := LoadConfig("")
 		assert.Nil(t, cfg)
 		assert.EqualError(t, err, "TARGET_USER_ID environment variable is required")
 	})
@@ -44,16 +64,17 @@ func TestLoadConfig(t *testing.T) {
 		defer clearEnv()
 
 		os.Setenv("LINE_ACCESS_TOKEN", "token123")
-		os.Setenv("TARGET_USER_ID", "user123")
+		os.Setenv("TARGET_USER_ID", testUserID)
 
-		cfg, err := LoadConfig()
+		cfg, err := LoadConfig("")
 		require.NoError(t, err)
 		require.NotNil(t, cfg)
 
 		assert.Equal(t, "token123", cfg.LineAccessToken)
-		assert.Equal(t, "user123", cfg.TargetUserID)
+		assert.Equal(t, testUserID, cfg.TargetUserID)
 		assert.Equal(t, "https://api.line.me/v2/bot/message/push", cfg.LineAPIURL)
 		assert.Equal(t, "https://hnrss.org/frontpage", cfg.RSSURL)
+		assert.Equal(t, []SourceConfig{{Type: "rss", URL: "https://hnrss.org/frontpage"}}, cfg.Sources)
 	})
 
 	t.Run("loads config with custom optional values", func(t *testing.T) {
@@ -61,27 +82,352 @@ func TestLoadConfig(t *testing.T) {
 		defer clearEnv()
 
 		os.Setenv("LINE_ACCESS_TOKEN", "token123")
-		os.Setenv("TARGET_USER_ID", "user123")
+		os.Setenv("TARGET_USER_ID", testUserID)
 		os.Setenv("LINE_API_URL", "https://custom.api.line.me/push")
 		os.Setenv("RSS_URL", "https://custom.rss.feed/news")
 
-		cfg, err := LoadConfig()
+		cfg, err := LoadConfig("")
 		require.NoError(t, err)
 		require.NotNil(t, cfg)
 
 		assert.Equal(t, "token123", cfg.LineAccessToken)
-		assert.Equal(t, "user123", cfg.TargetUserID)
+		assert.Equal(t, testUserID, cfg.TargetUserID)
 		assert.Equal(t, "https://custom.api.line.me/push", cfg.LineAPIURL)
 		assert.Equal(t, "https://custom.rss.feed/news", cfg.RSSURL)
+		assert.Equal(t, []SourceConfig{{Type: "rss", URL: "https://custom.rss.feed/news"}}, cfg.Sources)
+	})
+
+	t.Run("adds a reddit source per subreddit", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		os.Setenv("LINE_ACCESS_TOKEN", "token123")
+		os.Setenv("TARGET_USER_ID", testUserID)
+		os.Setenv("REDDIT_SUBREDDITS", "golang, programming")
+		os.Setenv("REDDIT_USER_AGENT", "imakoko-test/0.1")
+		os.Setenv("REDDIT_MIN_SCORE", "50")
+
+		cfg, err := LoadConfig("")
+		require.NoError(t, err)
+
+		assert.Equal(t, []SourceConfig{
+			{Type: "rss", URL: "https://hnrss.org/frontpage"},
+			{Type: "reddit", Subreddit: "golang", UserAgent: "imakoko-test/0.1", MinScore: 50},
+			{Type: "reddit", Subreddit: "programming", UserAgent: "imakoko-test/0.1", MinScore: 50},
+		}, cfg.Sources)
+	})
+
+	t.Run("returns error when REDDIT_MIN_SCORE is not a number", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		os.Setenv("LINE_ACCESS_TOKEN", "token123")
+		os.Setenv("TARGET_USER_ID", testUserID)
+		os.Setenv("REDDIT_SUBREDDITS", "golang")
+		os.Setenv("REDDIT_MIN_SCORE", "lots")
+
+		cfg, err := LoadConfig("")
+		assert.Nil(t, cfg)
+		assert.ErrorContains(t, err, "REDDIT_MIN_SCORE must be an integer")
+	})
+
+	t.Run("adds a github source for the listed repositories", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		os.Setenv("LINE_ACCESS_TOKEN", "token123")
+		os.Setenv("TARGET_USER_ID", testUserID)
+		os.Setenv("GITHUB_REPOS", "stretchr/testify,golang/go")
+		os.Setenv("GITHUB_TOKEN", "ghp_secret")
+		os.Setenv("GITHUB_INCLUDE_PRERELEASES", "true")
+
+		cfg, err := LoadConfig("")
+		require.NoError(t, err)
+
+		assert.Equal(t, []SourceConfig{
+			{Type: "rss", URL: "https://hnrss.org/frontpage"},
+			{Type: "github", Repos: []string{"stretchr/testify", "golang/go"}, Token: "ghp_secret", IncludePrereleases: true},
+		}, cfg.Sources)
+	})
+
+	t.Run("adds a scrape source for SCRAPE_URL", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		os.Setenv("LINE_ACCESS_TOKEN", "token123")
+		os.Setenv("TARGET_USER_ID", testUserID)
+		os.Setenv("SCRAPE_URL", "https://status.example.com/")
+		os.Setenv("SCRAPE_ITEM_SELECTOR", "li.incident")
+		os.Setenv("SCRAPE_TITLE_SELECTOR", "h3")
+		os.Setenv("SCRAPE_LINK_SELECTOR", "a.details")
+
+		cfg, err := LoadConfig("")
+		require.NoError(t, err)
+
+		assert.Equal(t, []SourceConfig{
+			{Type: "rss", URL: "https://hnrss.org/frontpage"},
+			{Type: "scrape", URL: "https://status.example.com/", ItemSelector: "li.incident", TitleSelector: "h3", LinkSelector: "a.details"},
+		}, cfg.Sources)
+	})
+
+	t.Run("adds sources from FEEDS_FILE", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		feedsPath := filepath.Join(t.TempDir(), "feeds.yaml")
+		require.NoError(t, os.WriteFile(feedsPath, []byte(`sources:
+  - type: rss
+    url: https://hnrss.org/frontpage
+  - type: rss
+    name: Lobsters
+    url: https://lobste.rs/rss
+    labels: [Tech]
+`), 0o600))
+
+		os.Setenv("LINE_ACCESS_TOKEN", "token123")
+		os.Setenv("TARGET_USER_ID", testUserID)
+		os.Setenv("FEEDS_FILE", feedsPath)
+
+		cfg, err := LoadConfig("")
+		require.NoError(t, err)
+
+		assert.Equal(t, []SourceConfig{
+			{Type: "rss", URL: "https://hnrss.org/frontpage"},
+			{Type: "rss", Name: "Lobsters", URL: "https://lobste.rs/rss", Labels: []string{"Tech"}},
+		}, cfg.Sources)
+	})
+
+	t.Run("returns error on unknown keys in FEEDS_FILE", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		feedsPath := filepath.Join(t.TempDir(), "feeds.yaml")
+		require.NoError(t, os.WriteFile(feedsPath, []byte("sources:\n  - type: rss\n    xmlUrl: https://lobste.rs/rss\n"), 0o600))
+
+		os.Setenv("LINE_ACCESS_TOKEN", "token123")
+		os.Setenv("TARGET_USER_ID", testUserID)
+		os.Setenv("FEEDS_FILE", feedsPath)
+
+		cfg, err := LoadConfig("")
+		assert.Nil(t, cfg)
+		assert.ErrorContains(t, err, "field xmlUrl not found")
 	})
 
 	t.Run("returns error when both required variables are missing", func(t *testing.T) {
 		clearEnv()
 		defer clearEnv()
 
-		cfg, err := LoadConfig()
+		cfg, err := LoadConfig("")
+		assert.Nil(t, cfg)
+		// Every problem is reported at once
+		assert.EqualError(t, err, "LINE_ACCESS_TOKEN environment variable is required\nTARGET_USER_ID environment variable is required")
+	})
+}
+
+func TestLoadConfig_File(t *testing.T) {
+	clearEnv := func() {
+		for _, key := range []string{"LINE_ACCESS_TOKEN", "LINE_ACCESS_TOKEN_FILE", "TARGET_USER_ID", "LINE_API_URL", "RSS_URL",
+			"IMAKOKO_CONFIG", "REDDIT_SUBREDDITS", "GITHUB_REPOS", "SCRAPE_URL", "FEEDS_FILE"} {
+			os.Unsetenv(key)
+		}
+	}
+	writeConfig := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "imakoko.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	const fileConfig = `line_access_token: file-token
+target_user_id: U11111111111111111111111111111111
+line_api_url: https://file.api.line.me/push
+sources:
+  - type: rss
+    name: Lobsters
+    url: https://lobste.rs/rss
+  - type: reddit
+    subreddit: golang
+    min_score: 50
+`
+
+	t.Run("loads settings and sources from the file", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		cfg, err := LoadConfig(writeConfig(t, fileConfig))
+		require.NoError(t, err)
+
+		assert.Equal(t, "file-token", cfg.LineAccessToken)
+		assert.Equal(t, "U11111111111111111111111111111111", cfg.TargetUserID)
+		assert.Equal(t, "https://file.api.line.me/push", cfg.LineAPIURL)
+		// The file lists its own sources, so the default feed isn't added
+		assert.Empty(t, cfg.RSSURL)
+		assert.Equal(t, []SourceConfig{
+			{Type: "rss", Name: "Lobsters", URL: "https://lobste.rs/rss"},
+			{Type: "reddit", Subreddit: "golang", MinScore: 50},
+		}, cfg.Sources)
+	})
+
+	t.Run("environment variables override the file", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		os.Setenv("LINE_ACCESS_TOKEN", "env-token")
+		os.Setenv("RSS_URL", "https://hnrss.org/best")
+		os.Setenv("REDDIT_SUBREDDITS", "programming")
+
+		cfg, err := LoadConfig(writeConfig(t, fileConfig))
+		require.NoError(t, err)
+
+		assert.Equal(t, "env-token", cfg.LineAccessToken)
+		assert.Equal(t, "U11111111111111111111111111111111", cfg.TargetUserID)
+		assert.Equal(t, "https://file.api.line.me/push", cfg.LineAPIURL)
+		assert.Equal(t, "https://hnrss.org/best", cfg.RSSURL)
+		assert.Equal(t, []SourceConfig{
+			{Type: "rss", URL: "https://hnrss.org/best"},
+			{Type: "rss", Name: "Lobsters", URL: "https://lobste.rs/rss"},
+			{Type: "reddit", Subreddit: "golang", MinScore: 50},
+			{Type: "reddit", Subreddit: "programming"},
+		}, cfg.Sources)
+	})
+
+	t.Run("defaults apply to settings missing from the file", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		cfg, err := LoadConfig(writeConfig(t, "line_access_token: file-token\ntarget_user_id: U11111111111111111111111111111111\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://api.line.me/v2/bot/message/push", cfg.LineAPIURL)
+		assert.Equal(t, "https://hnrss.org/frontpage", cfg.RSSURL)
+		assert.Equal(t, []SourceConfig{{Type: "rss", URL: "https://hnrss.org/frontpage"}}, cfg.Sources)
+	})
+
+	t.Run("reads the file named by IMAKOKO_CONFIG", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		os.Setenv("IMAKOKO_CONFIG", writeConfig(t, fileConfig))
+
+		cfg, err := LoadConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, "file-token", cfg.LineAccessToken)
+	})
+
+	t.Run("path argument wins over IMAKOKO_CONFIG", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		os.Setenv("IMAKOKO_CONFIG", writeConfig(t, fileConfig))
+
+		cfg, err := LoadConfig(writeConfig(t, "line_access_token: flag-token\ntarget_user_id: U22222222222222222222222222222222\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "flag-token", cfg.LineAccessToken)
+		assert.Equal(t, "U22222222222222222222222222222222", cfg.TargetUserID)
+	})
+
+	t.Run("empty file behaves like no file", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		os.Setenv("LINE_ACCESS_TOKEN", "env-token")
+		os.Setenv("TARGET_USER_ID", "U33333333333333333333333333333333")
+
+		cfg, err := LoadConfig(writeConfig(t, ""))
+		require.NoError(t, err)
+		assert.Equal(t, "https://hnrss.org/frontpage", cfg.RSSURL)
+	})
+
+	t.Run("returns error on unknown keys", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		cfg, err := LoadConfig(writeConfig(t, "line_access_token: file-token\ntarget_user: U11111111111111111111111111111111\n"))
+		assert.Nil(t, cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse config file")
+		assert.Contains(t, err.Error(), "line 2: field target_user not found")
+	})
+
+	t.Run("returns error on unknown source keys", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		cfg, err := LoadConfig(writeConfig(t, "sources:\n  - type: reddit\n    sub: golang\n"))
+		assert.Nil(t, cfg)
+		assert.ErrorContains(t, err, "line 3: field sub not found")
+	})
+
+	t.Run("returns error when the file is missing", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		cfg, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Nil(t, cfg)
+		assert.ErrorContains(t, err, "failed to read config file")
+	})
+
+	t.Run("resolves secret references in the file", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		tokenPath := writeSecret(t, "file-secret-token\n", 0o600)
+		os.Setenv("IMAKOKO_TEST_GITHUB_TOKEN", "ghp_from_env")
+		defer os.Unsetenv("IMAKOKO_TEST_GITHUB_TOKEN")
+
+		cfg, err := LoadConfig(writeConfig(t, `line_access_token: file://`+tokenPath+`
+target_user_id: U11111111111111111111111111111111
+sources:
+  - type: github
+    repos: [golang/go]
+    token: env://IMAKOKO_TEST_GITHUB_TOKEN
+`))
+		require.NoError(t, err)
+		assert.Equal(t, "file-secret-token", cfg.LineAccessToken)
+		assert.Equal(t, "ghp_from_env", cfg.Sources[0].Token)
+
+		result := cfg.String()
+		assert.NotContains(t, result, "file-secret-token")
+		assert.NotContains(t, result, "ghp_from_env")
+		assert.Contains(t, result, `github(golang/go) token="gh***nv"`)
+	})
+
+	t.Run("LINE_ACCESS_TOKEN_FILE overrides the file", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		os.Setenv("LINE_ACCESS_TOKEN_FILE", writeSecret(t, "mounted-token\n", 0o400))
+
+		cfg, err := LoadConfig(writeConfig(t, fileConfig))
+		require.NoError(t, err)
+		assert.Equal(t, "mounted-token", cfg.LineAccessToken)
+	})
+
+	t.Run("rejects secret files readable by others", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		os.Setenv("LINE_ACCESS_TOKEN_FILE", writeSecret(t, "mounted-token", 0o644))
+
+		cfg, err := LoadConfig(writeConfig(t, fileConfig))
+		assert.Nil(t, cfg)
+		assert.ErrorContains(t, err, "LINE_ACCESS_TOKEN_FILE: secret file")
+	})
+
+	t.Run("rejects unresolvable references", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		cfg, err := LoadConfig(writeConfig(t, "line_access_token: env://IMAKOKO_TEST_UNSET_TOKEN\n"))
+		assert.Nil(t, cfg)
+		assert.EqualError(t, err, "line_access_token: secret reference env://IMAKOKO_TEST_UNSET_TOKEN: environment variable IMAKOKO_TEST_UNSET_TOKEN is not set")
+	})
+
+	t.Run("file still requires the token", func(t *testing.T) {
+		clearEnv()
+		defer clearEnv()
+
+		cfg, err := LoadConfig(writeConfig(t, "target_user_id: U11111111111111111111111111111111\n"))
 		assert.Nil(t, cfg)
-		// LINE_ACCESS_TOKEN is checked first
 		assert.EqualError(t, err, "LINE_ACCESS_TOKEN environment variable is required")
 	})
 }
@@ -186,3 +532,204 @@ func TestConfig_String_Format(t *testing.T) {
 	assert.Contains(t, result, `"https://rss.example.com"`)
 	assert.Contains(t, result, `"to***45"`)
 }
+
+func TestLoadConfig_ExampleFile(t *testing.T) {
+	t.Setenv("LINE_ACCESS_TOKEN", "token123")
+	t.Setenv("GITHUB_TOKEN", "ghp_example")
+	for _, key := range []string{"LINE_ACCESS_TOKEN_FILE", "TARGET_USER_ID", "LINE_API_URL", "RSS_URL", "IMAKOKO_CONFIG", "REDDIT_SUBREDDITS", "GITHUB_REPOS", "SCRAPE_URL", "FEEDS_FILE"} {
+		t.Setenv(key, "")
+	}
+
+	cfg, err := LoadConfig("imakoko.example.yaml")
+	require.NoError(t, err)
+
+	_, err = NewSources(cfg.Sources)
+	require.NoError(t, err)
+	assert.Len(t, cfg.Sources, 5)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := func() *Config {
+		return &Config{
+			LineAccessToken: "token123",
+			TargetUserID:    testUserID,
+			LineAPIURL:      "https://api.line.me/v2/bot/message/push",
+			RSSURL:          "https://hnrss.org/frontpage",
+			Sources:         []SourceConfig{{Type: "rss", URL: "https://hnrss.org/frontpage"}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(c *Config)
+		expected []string
+	}{
+		{
+			name:   "valid config",
+			modify: func(c *Config) {},
+		},
+		{
+			name:   "group and room IDs are accepted",
+			modify: func(c *Config) { c.TargetUserID = "C0123456789abcdef0123456789abcdef" },
+		},
+		{
+			name:     "malformed target ID",
+			modify:   func(c *Config) { c.TargetUserID = "U0123456789ABCDEF0123456789ABCDEF" },
+			expected: []string{`TARGET_USER_ID "U0123456789ABCDEF0123456789ABCDEF" is not a LINE user, group or room ID`},
+		},
+		{
+			name:     "relative LINE API URL",
+			modify:   func(c *Config) { c.LineAPIURL = "/v2/bot/message/push" },
+			expected: []string{`LINE_API_URL "/v2/bot/message/push" must be an absolute http(s) URL`},
+		},
+		{
+			name:     "non-http RSS URL",
+			modify:   func(c *Config) { c.RSSURL = "file:///etc/passwd" },
+			expected: []string{`RSS_URL "file:///etc/passwd" must be an absolute http(s) URL`},
+		},
+		{
+			name:     "no sources",
+			modify:   func(c *Config) { c.RSSURL = ""; c.Sources = nil },
+			expected: []string{"at least one source is required"},
+		},
+		{
+			name: "unknown and invalid sources",
+			modify: func(c *Config) {
+				c.Sources = append(c.Sources, SourceConfig{Type: "mastodon"}, SourceConfig{Type: "github", Repos: []string{"golang"}})
+			},
+			expected: []string{
+				`source 2: unknown source type "mastodon"`,
+				`source 3 (github): invalid repository "golang": expected owner/repo`,
+			},
+		},
+		{
+			name:   "valid templates",
+			modify: func(c *Config) { c.Format = FormatConfig{Template: "{{.Title}}", Header: "{{len .Items}} items"} },
+		},
+		{
+			name:     "invalid template",
+			modify:   func(c *Config) { c.Format = FormatConfig{Template: "{{.Title"} },
+			expected: []string{"format: invalid template: template: template:1: unclosed action"},
+		},
+		{
+			name: "targets instead of TARGET_USER_ID",
+			modify: func(c *Config) {
+				c.TargetUserID = ""
+				c.Targets = []TargetConfig{{UserID: "C0123456789abcdef0123456789abcdef", Locale: "en"}}
+			},
+		},
+		{
+			name: "invalid targets and locales",
+			modify: func(c *Config) {
+				c.Locale = "fr"
+				c.Targets = []TargetConfig{{UserID: "group", Locale: "de"}}
+			},
+			expected: []string{
+				`targets[0]: user_id "group" is not a LINE user, group or room ID`,
+				`targets[0]: locale "de" is not supported, use one of: en, ja`,
+				`LOCALE: locale "fr" is not supported, use one of: en, ja`,
+			},
+		},
+		{
+			name:     "unknown grouping",
+			modify:   func(c *Config) { c.Format = FormatConfig{GroupBy: "author"} },
+			expected: []string{`format: unknown group_by "author", expected source, domain or category`},
+		},
+		{
+			name:     "similarity out of range",
+			modify:   func(c *Config) { c.Dedupe.Similarity = 1.5 },
+			expected: []string{"dedupe.similarity 1.5 must be between 0 and 1"},
+		},
+		{
+			name:   "invalid translation",
+			modify: func(c *Config) { c.Translation = &TranslationConfig{Target: "fr"} },
+			expected: []string{
+				`translation.endpoint "" must be an absolute http(s) URL`,
+				`translation.target: locale "fr" is not supported, use one of: en, ja`,
+			},
+		},
+		{
+			name: "unsupported languages",
+			modify: func(c *Config) {
+				c.Languages = []string{"ja", "jp"}
+				c.Targets = []TargetConfig{{UserID: "C0123456789abcdef0123456789abcdef", Languages: []string{"english"}}}
+			},
+			expected: []string{
+				`targets[0]: language "english" is not supported, use one of: en, ja, zh, ko, de, fr, es`,
+				`languages: language "jp" is not supported, use one of: en, ja, zh, ko, de, fr, es`,
+			},
+		},
+		{
+			name:     "unknown broken link action",
+			modify:   func(c *Config) { c.LinkCheck = &LinkCheckConfig{Broken: "hide"} },
+			expected: []string{`link_check.broken "hide" must be drop or annotate`},
+		},
+		{
+			name:   "known time zone",
+			modify: func(c *Config) { c.Timezone = "Asia/Tokyo" },
+		},
+		{
+			name:     "unknown time zone",
+			modify:   func(c *Config) { c.Timezone = "Mars/Olympus" },
+			expected: []string{`TIMEZONE "Mars/Olympus" is not a known time zone`},
+		},
+		{
+			name:   "json debug logging",
+			modify: func(c *Config) { c.Log = LogConfig{Format: "json", Level: "debug"} },
+		},
+		{
+			name:     "unknown log format",
+			modify:   func(c *Config) { c.Log.Format = "logfmt" },
+			expected: []string{`LOG_FORMAT "logfmt" must be text or json`},
+		},
+		{
+			name: "every problem at once",
+			modify: func(c *Config) {
+				c.LineAccessToken = ""
+				c.TargetUserID = ""
+				c.LineAPIURL = "not a url"
+			},
+			expected: []string{
+				"LINE_ACCESS_TOKEN environment variable is required",
+				"TARGET_USER_ID environment variable is required",
+				`LINE_API_URL "not a url" must be an absolute http(s) URL`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if len(tt.expected) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.expected, strings.Split(err.Error(), "\n"))
+		})
+	}
+}
+
+func TestConfig_Targets(t *testing.T) {
+	cfg := &Config{
+		TargetUserID: testUserID,
+		Locale:       "en",
+		Targets: []TargetConfig{
+			{UserID: "C0123456789abcdef0123456789abcdef", Locale: "ja"},
+			{UserID: testUserID, Locale: "ja"},
+			{UserID: "R0123456789abcdef0123456789abcdef"},
+		},
+	}
+
+	assert.Equal(t, []TargetConfig{
+		{UserID: testUserID, Locale: "en"},
+		{UserID: "C0123456789abcdef0123456789abcdef", Locale: "ja"},
+		{UserID: "R0123456789abcdef0123456789abcdef", Locale: "en"},
+	}, cfg.targets())
+	assert.Equal(t, []string{"en", "ja"}, cfg.locales())
+
+	assert.Equal(t, []string{defaultLocale}, (&Config{}).locales())
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reasons sources give for leaving items out of the digest
+const (
+	dropStickied   = "stickied"
+	dropNSFW       = "nsfw"
+	dropLowScore   = "low_score"
+	dropDraft      = "draft"
+	dropPrerelease = "prerelease"
+	dropTooOld     = "too_old"
+	dropDuplicate  = "duplicate"
+	dropSimilar    = "similar"
+	dropRankedOut  = "ranked_out"
+	dropLanguage   = "language"
+	dropDeadLink   = "dead_link"
+)
+
+// Digest is one delivery worth of items plus the context formatters show around them
+type Digest struct {
+	Items []Item
+	// Date is when the digest was assembled, in the configured timezone
+	Date time.Time
+	// Dropped counts the items left out, by reason, in the order the reasons first occurred
+	Dropped []DropCount
+}
+
+// DropCount is the number of items left out for one reason
+type DropCount struct {
+	Reason string
+	Count  int
+}
+
+// SourceCount is the number of digest items that came from one source
+type SourceCount struct {
+	Source string
+	Count  int
+}
+
+// SourceCounts tallies the items per source in the order the sources first appear
+func (d *Digest) SourceCounts() []SourceCount {
+	var counts []SourceCount
+	index := make(map[string]int)
+	for _, item := range d.Items {
+		i, ok := index[item.Source]
+		if !ok {
+			i = len(counts)
+			index[item.Source] = i
+			counts = append(counts, SourceCount{Source: item.Source})
+		}
+		counts[i].Count++
+	}
+	return counts
+}
+
+// DroppedTotal is the number of items left out for any reason
+func (d *Digest) DroppedTotal() int {
+	total := 0
+	for _, dropped := range d.Dropped {
+		total += dropped.Count
+	}
+	return total
+}
+
+// digestHeader summarizes the digest, e.g. "10月16日(金) 朝のニュース: 12件 (HN 8, Lobsters 4)"
+func digestHeader(d *Digest, l *Localizer) string {
+	header := l.T(msgDigestHeader, l.Date(d.Date), l.PartOfDay(d.Date), len(d.Items))
+
+	counts := d.SourceCounts()
+	if len(counts) == 0 {
+		return header
+	}
+	parts := make([]string, len(counts))
+	for i, c := range counts {
+		parts[i] = fmt.Sprintf("%s %d", c.Source, c.Count)
+	}
+	return header + l.T(msgDigestSources, strings.Join(parts, ", "))
+}
+
+// digestFooter explains what was left out, e.g. "除外: 5件 (スコア不足 3, 固定投稿 2)",
+// or returns "" when nothing was
+func digestFooter(d *Digest, l *Localizer) string {
+	total := d.DroppedTotal()
+	if total == 0 {
+		return ""
+	}
+	parts := make([]string, len(d.Dropped))
+	for i, dropped := range d.Dropped {
+		parts[i] = fmt.Sprintf("%s %d", l.DropReason(dropped.Reason), dropped.Count)
+	}
+	return l.T(msgDigestFooter, total, strings.Join(parts, ", "))
+}
+
+// summaryFormatter wraps a Formatter with the built-in digest header and footer messages
+type summaryFormatter struct {
+	Formatter
+	localizer *Localizer
+	header    bool
+	footer    bool
+}
+
+func (f summaryFormatter) Format(d *Digest) ([]string, error) {
+	messages, err := f.Formatter.Format(d)
+	if err != nil {
+		return nil, err
+	}
+	if f.header {
+		messages = append([]string{digestHeader(d, f.localizer)}, messages...)
+	}
+	if f.footer {
+		messages = appendNonEmpty(messages, digestFooter(d, f.localizer))
+	}
+	return messages, nil
+}
+
+// dropCounter tallies the items sources filter out during one collection
+type dropCounter struct {
+	mu     sync.Mutex
+	counts []DropCount
+}
+
+type dropCounterKey struct{}
+
+// withDropCounter returns a context in which sources can report filtered items
+func withDropCounter(ctx context.Context) (context.Context, *dropCounter) {
+	counter := &dropCounter{}
+	return context.WithValue(ctx, dropCounterKey{}, counter), counter
+}
+
+// recordDropped notes that an item was left out for reason; it does nothing
+// when the context carries no counter
+func recordDropped(ctx context.Context, reason string) {
+	counter, ok := ctx.Value(dropCounterKey{}).(*dropCounter)
+	if !ok {
+		return
+	}
+	counter.add(reason, 1)
+}
+
+func (c *dropCounter) add(reason string, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.counts {
+		if c.counts[i].Reason == reason {
+			c.counts[i].Count += n
+			return
+		}
+	}
+	c.counts = append(c.counts, DropCount{Reason: reason, Count: n})
+}
+
+// list returns a copy of the counts so far
+func (c *dropCounter) list() []DropCount {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]DropCount(nil), c.counts...)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDigest() *Digest {
+	return &Digest{
+		Items: []Item{
+			{Title: "Go 1.27 is released", Link: "https://go.dev/blog/go1.27", Source: "HN"},
+			{Title: "Why we left Kubernetes", Link: "https://example.com/k8s", Source: "Lobsters"},
+			{Title: "Show HN: imakoko", Link: "https://example.com/imakoko", Source: "HN"},
+		},
+		Date: time.Date(2026, 10, 16, 7, 30, 0, 0, time.FixedZone("JST", 9*60*60)),
+		Dropped: []DropCount{
+			{Reason: dropLowScore, Count: 3},
+			{Reason: dropStickied, Count: 1},
+		},
+	}
+}
+
+func TestDigest_SourceCounts(t *testing.T) {
+	d := newTestDigest()
+
+	assert.Equal(t, []SourceCount{{Source: "HN", Count: 2}, {Source: "Lobsters", Count: 1}}, d.SourceCounts())
+	assert.Equal(t, 4, d.DroppedTotal())
+}
+
+func newTestLocalizer(t *testing.T, locale string) *Localizer {
+	l, err := NewLocalizer(locale)
+	require.NoError(t, err)
+	return l
+}
+
+func TestDigestHeader(t *testing.T) {
+	ja, en := newTestLocalizer(t, "ja"), newTestLocalizer(t, "en")
+
+	d := newTestDigest()
+	assert.Equal(t, "10月16日(金) 朝のニュース: 3件 (HN 2, Lobsters 1)", digestHeader(d, ja))
+	assert.Equal(t, "Morning news for Fri, Oct 16: 3 items (HN 2, Lobsters 1)", digestHeader(d, en))
+
+	d.Date = d.Date.Add(12 * time.Hour)
+	d.Items = nil
+	assert.Equal(t, "10月16日(金) 夜のニュース: 0件", digestHeader(d, ja))
+}
+
+func TestDigestFooter(t *testing.T) {
+	ja, en := newTestLocalizer(t, "ja"), newTestLocalizer(t, "en")
+
+	d := newTestDigest()
+	assert.Equal(t, "除外: 4件 (スコア不足 3, 固定投稿 1)", digestFooter(d, ja))
+	assert.Equal(t, "Filtered out: 4 (low score 3, stickied 1)", digestFooter(d, en))
+
+	d.Dropped = []DropCount{{Reason: "unlisted", Count: 2}}
+	assert.Equal(t, "除外: 2件 (unlisted 2)", digestFooter(d, ja))
+
+	d.Dropped = nil
+	assert.Equal(t, "", digestFooter(d, ja))
+}
+
+func TestSummaryFormatter(t *testing.T) {
+	d := newTestDigest()
+	ja := newTestLocalizer(t, "ja")
+
+	t.Run("wraps the items with header and footer", func(t *testing.T) {
+		messages, err := summaryFormatter{Formatter: hackerNewsFormatter{}, localizer: ja, header: true, footer: true}.Format(d)
+		require.NoError(t, err)
+		require.Len(t, messages, 5)
+		assert.Equal(t, digestHeader(d, ja), messages[0])
+		assert.Equal(t, "1. Go 1.27 is released\nhttps://go.dev/blog/go1.27", messages[1])
+		assert.Equal(t, digestFooter(d, ja), messages[4])
+	})
+
+	t.Run("omits the footer when nothing was dropped", func(t *testing.T) {
+		messages, err := summaryFormatter{Formatter: hackerNewsFormatter{}, localizer: ja, footer: true}.Format(&Digest{Items: d.Items})
+		require.NoError(t, err)
+		assert.Len(t, messages, 3)
+	})
+}
+
+func TestRecordDropped(t *testing.T) {
+	// Without a counter in the context sources can still report
+	recordDropped(context.Background(), dropNSFW)
+
+	ctx, counter := withDropCounter(context.Background())
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recordDropped(ctx, dropLowScore)
+		}()
+	}
+	wg.Wait()
+	recordDropped(ctx, dropDraft)
+
+	assert.Equal(t, []DropCount{{Reason: dropLowScore, Count: 10}, {Reason: dropDraft, Count: 1}}, counter.list())
+}
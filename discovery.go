@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// feedMediaTypes are the link types recognised by feed autodiscovery
+var feedMediaTypes = map[string]bool{
+	"application/rss+xml":  true,
+	"application/atom+xml": true,
+}
+
+// isHTMLDocument reports whether body looks like a web page rather than a feed.
+// The body is sniffed instead of trusting Content-Type, which feeds often get wrong.
+func isHTMLDocument(body []byte) bool {
+	return strings.HasPrefix(http.DetectContentType(body), "text/html")
+}
+
+// discoverFeeds returns the absolute URLs of the RSS and Atom feeds an HTML page
+// advertises with <link rel="alternate">, in document order and without duplicates
+func discoverFeeds(body []byte, pageURL *url.URL) ([]string, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML: %w", err)
+	}
+
+	base := pageURL
+	if href, ok := doc.Find("base[href]").Attr("href"); ok {
+		if u, err := pageURL.Parse(href); err == nil {
+			base = u
+		}
+	}
+
+	var feeds []string
+	seen := make(map[string]bool)
+	doc.Find(`link[rel~="alternate" i][href]`).Each(func(_ int, link *goquery.Selection) {
+		mediaType, _, err := mime.ParseMediaType(link.AttrOr("type", ""))
+		if err != nil || !feedMediaTypes[mediaType] {
+			return
+		}
+		u, err := base.Parse(strings.TrimSpace(link.AttrOr("href", "")))
+		if err != nil {
+			return
+		}
+		if feed := u.String(); !seen[feed] {
+			seen[feed] = true
+			feeds = append(feeds, feed)
+		}
+	})
+	return feeds, nil
+}
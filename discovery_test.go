@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example Blog</title>
+  <entry>
+    <title>Atom Story</title>
+    <link rel="self" href="https://blog.example.com/atom/1"/>
+    <link href="https://blog.example.com/posts/1"/>
+  </entry>
+</feed>`
+
+func TestIsHTMLDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected bool
+	}{
+		{name: "html5 page", body: "<!DOCTYPE html><html><head></head></html>", expected: true},
+		{name: "html with leading whitespace", body: "\n\n  <html lang=\"ja\">", expected: true},
+		{name: "rss with xml declaration", body: testRSS, expected: false},
+		{name: "rss without declaration", body: `<rss version="2.0"><channel></channel></rss>`, expected: false},
+		{name: "atom feed", body: testAtom, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isHTMLDocument([]byte(tt.body)))
+		})
+	}
+}
+
+func TestDiscoverFeeds(t *testing.T) {
+	pageURL, _ := url.Parse("https://blog.example.com/ja/index.html")
+
+	t.Run("resolves relative hrefs and skips non-feed alternates", func(t *testing.T) {
+		body := `<html><head>
+			<link rel="stylesheet" href="/style.css">
+			<link rel="alternate" hreflang="en" href="/en/">
+			<link rel="alternate" type="application/rss+xml" title="RSS" href="feed.xml">
+			<link rel="alternate" type="application/atom+xml" href="/atom.xml">
+			<link rel="Alternate" type="application/rss+xml; charset=utf-8" href="https://blog.example.com/ja/feed.xml">
+		</head></html>`
+
+		feeds, err := discoverFeeds([]byte(body), pageURL)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://blog.example.com/ja/feed.xml", "https://blog.example.com/atom.xml"}, feeds)
+	})
+
+	t.Run("honours base href", func(t *testing.T) {
+		body := `<html><head>
+			<base href="https://cdn.example.net/">
+			<link rel="alternate" type="application/rss+xml" href="rss">
+		</head></html>`
+
+		feeds, err := discoverFeeds([]byte(body), pageURL)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://cdn.example.net/rss"}, feeds)
+	})
+
+	t.Run("returns nothing when no feed is advertised", func(t *testing.T) {
+		feeds, err := discoverFeeds([]byte(`<html><head><title>x</title></head></html>`), pageURL)
+		require.NoError(t, err)
+		assert.Empty(t, feeds)
+	})
+}
+
+func TestFetchHNRSS_Autodiscovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/single/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<!DOCTYPE html><html><head><link rel="alternate" type="application/rss+xml" href="feed.xml"></head><body>Home</body></html>`))
+		case "/single/feed.xml":
+			w.Write([]byte(testRSS))
+		case "/atom/":
+			w.Write([]byte(`<html><head><link rel="alternate" type="application/atom+xml" href="/atom/feed"></head></html>`))
+		case "/atom/feed":
+			w.Write([]byte(testAtom))
+		case "/several/":
+			w.Write([]byte(`<html><head>
+				<link rel="alternate" type="application/rss+xml" href="/several/posts.xml">
+				<link rel="alternate" type="application/atom+xml" href="/several/comments.atom">
+			</head></html>`))
+		case "/none/":
+			w.Write([]byte(`<html><head><title>No feeds here</title></head></html>`))
+		case "/broken/":
+			w.Write([]byte(`<html><head><link rel="alternate" type="application/atom+xml" href="/gone"></head></html>`))
+		case "/loop/":
+			w.Write([]byte(`<html><head><link rel="alternate" type="application/rss+xml" href="/none/"></head></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("follows the advertised RSS feed", func(t *testing.T) {
+		items, err := getNews(context.Background(), server.URL+"/single/")
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "First Story", items[0].Title)
+	})
+
+	t.Run("follows the advertised Atom feed", func(t *testing.T) {
+		items, err := getNews(context.Background(), server.URL+"/atom/")
+		require.NoError(t, err)
+		assert.Equal(t, []Item{{Title: "Atom Story", Link: "https://blog.example.com/posts/1"}}, items)
+	})
+
+	t.Run("lists candidates when several feeds are advertised", func(t *testing.T) {
+		_, err := getNews(context.Background(), server.URL+"/several/")
+		assert.EqualError(t, err, server.URL+"/several/ advertises several feeds, use one of: "+
+			server.URL+"/several/posts.xml, "+server.URL+"/several/comments.atom")
+	})
+
+	t.Run("explains pages without a feed", func(t *testing.T) {
+		_, err := getNews(context.Background(), server.URL+"/none/")
+		assert.EqualError(t, err, server.URL+"/none/ is an HTML page that advertises no RSS or Atom feed")
+	})
+
+	t.Run("does not follow a second page", func(t *testing.T) {
+		_, err := getNews(context.Background(), server.URL+"/loop/")
+		assert.EqualError(t, err, "feed "+server.URL+"/none/ advertised by "+server.URL+"/loop/ is an HTML page")
+	})
+
+	t.Run("wraps errors fetching the advertised feed", func(t *testing.T) {
+		_, err := fetchHNRSS(context.Background(), server.URL+"/broken/")
+		assert.EqualError(t, err, "feed "+server.URL+"/gone advertised by "+server.URL+"/broken/: unexpected status code: 404")
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// dryRunSender implements MessageSender by recording the exact LINE push payloads
+// instead of calling the API
+type dryRunSender struct {
+	targetUserID string
+	dir          string
+	out          io.Writer
+	batches      int
+}
+
+// NewDryRunSender creates a sender that writes each batch's payload to its own file
+// in dir, or to out when dir is empty
+func NewDryRunSender(targetUserID, dir string, out io.Writer) MessageSender {
+	return &dryRunSender{
+		targetUserID: targetUserID,
+		dir:          dir,
+		out:          out,
+	}
+}
+
+// Send implements MessageSender interface for dryRunSender
+func (s *dryRunSender) Send(messages []string) error {
+	payload, err := buildLinePayload(messages, s.targetUserID)
+	if err != nil {
+		return err
+	}
+	s.batches++
+
+	if s.dir == "" {
+		if _, err := fmt.Fprintf(s.out, "%s\n", payload); err != nil {
+			return fmt.Errorf("failed to write payload: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create dry-run directory: %w", err)
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("batch-%03d.json", s.batches))
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+	return nil
+}
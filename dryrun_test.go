@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunSender_RecordsExactPayload(t *testing.T) {
+	var posted []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	messages := []string{"1. Story <b>\nhttps://example.com/?a=1&b=2", "2. 日本語\nhttps://example.jp"}
+	require.NoError(t, sendLineMessage(http.DefaultClient, server.URL, "test-token", messages, "U123456"))
+
+	var out bytes.Buffer
+	sender := NewDryRunSender("U123456", "", &out)
+	require.NoError(t, sender.Send(messages))
+
+	assert.Equal(t, string(posted)+"\n", out.String())
+}
+
+func TestDryRunSender_WritesOneFilePerBatch(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "payloads")
+	sender := NewDryRunSender("U123456", dir, nil)
+
+	messages := make([]string, 7)
+	for i := range messages {
+		messages[i] = fmt.Sprintf("Message %d", i+1)
+	}
+	require.NoError(t, sendBatchLineMessage(context.Background(), sender, messages))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "batch-001.json"), filepath.Join(dir, "batch-002.json")}, files)
+
+	second, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"to":"U123456","messages":[{"type":"text","text":"Message 6"},{"type":"text","text":"Message 7"}]}`, string(second))
+}
+
+func TestDryRunSender_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		errorMsg string
+	}{
+		{
+			name:     "rejects message exceeding 5000 characters",
+			messages: []string{"short", strings.Repeat("a", MaxMessageLength+1)},
+			errorMsg: "message 2 exceeds LINE's 5000 character limit (has 5001 characters)",
+		},
+		{
+			name:     "rejects batches larger than 5",
+			messages: []string{"1", "2", "3", "4", "5", "6"},
+			errorMsg: "batch has 6 messages, LINE accepts at most 5 per push",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			sender := NewDryRunSender("U123456", "", &out)
+
+			err := sender.Send(tt.messages)
+			assert.EqualError(t, err, tt.errorMsg)
+			assert.Empty(t, out.String())
+		})
+	}
+}
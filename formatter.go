@@ -1,12 +1,88 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+)
 
-// FormatHackerNews converts HackerNews items to LINE message strings
+// Formatter renders a digest into LINE message texts
+type Formatter interface {
+	Format(d *Digest) ([]string, error)
+}
+
+// itemRenderer renders a single item with its position in the digest
+type itemRenderer interface {
+	renderItem(item Item, number, total int) (string, error)
+}
+
+// hackerNewsFormatter implements Formatter with the built-in numbered layout.
+// Items get their summary, or else the page description, on a line of its own, and with
+// a localizer, items other sources also covered get an "also: N more sources" line.
+type hackerNewsFormatter struct {
+	localizer *Localizer
+}
+
+func (f hackerNewsFormatter) Format(d *Digest) ([]string, error) {
+	messages := make([]string, len(d.Items))
+	for i, item := range d.Items {
+		messages[i], _ = f.renderItem(item, i+1, len(d.Items))
+	}
+	return messages, nil
+}
+
+func (f hackerNewsFormatter) renderItem(item Item, number, _ int) (string, error) {
+	msg := formatHackerNewsItem(item, number, f.localizer)
+	var note string
+	if f.localizer != nil && len(item.AlsoSources) > 0 {
+		note = "\n" + f.localizer.AlsoSources(len(item.AlsoSources))
+	}
+	summary := item.Summary
+	if summary == "" {
+		summary = item.OpenGraph.Description
+	}
+	// The summary gives way so the message stays within LINE's limit
+	if summary != "" {
+		room := MaxMessageLength - len(msg) - len(note) - len("\n")
+		if room > 0 {
+			msg += "\n" + truncateBytes(summary, room)
+		}
+	}
+	return msg + note, nil
+}
+
+// FormatHackerNews converts HackerNews items to LINE message strings, with reading
+// times in the default locale
 func FormatHackerNews(items []Item) []string {
+	l, _ := NewLocalizer(defaultLocale)
 	messages := make([]string, len(items))
 	for i, item := range items {
-		messages[i] = fmt.Sprintf("%d. %s\n%s", i+1, item.Title, item.Link)
+		messages[i] = formatHackerNewsItem(item, i+1, l)
 	}
 	return messages
 }
+
+// formatHackerNewsItem renders the numbered title and link. With a localizer the
+// reading time, when known, follows the link.
+func formatHackerNewsItem(item Item, number int, l *Localizer) string {
+	title := itemMarkers(item) + item.Title
+	link := item.Link
+	if l != nil && item.ReadingMinutes > 0 {
+		link += " ・ " + l.ReadingTime(item.ReadingMinutes)
+	}
+	if item.OriginalTitle != "" {
+		return fmt.Sprintf("%d. %s\n%s\n%s", number, title, item.OriginalTitle, link)
+	}
+	return fmt.Sprintf("%d. %s\n%s", number, title, link)
+}
+
+// itemMarkers returns the markers shown before the title: 🔒 for paywalled sites
+// and ⚠️ for links that failed the link check
+func itemMarkers(item Item) string {
+	var markers string
+	if item.Paywalled {
+		markers += "🔒 "
+	}
+	if item.LinkBroken {
+		markers += "⚠️ "
+	}
+	return markers
+}
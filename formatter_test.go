@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFormatHackerNews(t *testing.T) {
@@ -132,3 +133,32 @@ func TestFormatHackerNews(t *testing.T) {
 		assert.Contains(t, result[99], "100. Article")
 	})
 }
+
+func TestHackerNewsFormatter(t *testing.T) {
+	items := []Item{
+		{Title: "First Article", Link: "https://example.com/1"},
+		{Title: "Second Article", Link: "https://example.com/2"},
+	}
+
+	messages, err := hackerNewsFormatter{}.Format(&Digest{Items: items})
+
+	require.NoError(t, err)
+	assert.Equal(t, FormatHackerNews(items), messages)
+}
+
+func TestHackerNewsFormatter_Description(t *testing.T) {
+	item := Item{
+		Title:     "Go 1.27 released",
+		Link:      "https://go.dev/blog/go1.27",
+		OpenGraph: OpenGraph{Description: "Generic methods arrive."},
+	}
+
+	msg, err := hackerNewsFormatter{}.renderItem(item, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1. Go 1.27 released\nhttps://go.dev/blog/go1.27\nGeneric methods arrive.", msg)
+
+	item.Summary = "The Go team released Go 1.27."
+	msg, err = hackerNewsFormatter{}.renderItem(item, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1. Go 1.27 released\nhttps://go.dev/blog/go1.27\nThe Go team released Go 1.27.", msg, "the summary is preferred")
+}
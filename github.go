@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+	defaultGitHubMaxAge = 24 * time.Hour
+)
+
+func init() {
+	RegisterSource("github", newGitHubSource)
+}
+
+// githubRelease is the subset of the GitHub releases API response we use
+type githubRelease struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	HTMLURL     string    `json:"html_url"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	CreatedAt   time.Time `json:"created_at"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// githubSource implements Source for the releases of a set of repositories
+type githubSource struct {
+	httpClient         *http.Client
+	name               string
+	apiURL             string
+	token              string
+	repos              []string
+	includePrereleases bool
+	includeDrafts      bool
+	maxAge             time.Duration
+	now                func() time.Time
+}
+
+func newGitHubSource(cfg SourceConfig) (Source, error) {
+	if len(cfg.Repos) == 0 {
+		return nil, errors.New("github source requires at least one repository")
+	}
+	for _, repo := range cfg.Repos {
+		if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid repository %q: expected owner/repo", repo)
+		}
+	}
+	name := cfg.Name
+	if name == "" {
+		name = "GitHub"
+	}
+	apiURL := cfg.URL
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+	maxAge := cfg.MaxAge
+	if maxAge == 0 {
+		maxAge = defaultGitHubMaxAge
+	}
+	return &githubSource{
+		httpClient:         rssHTTPClient,
+		name:               name,
+		apiURL:             strings.TrimSuffix(apiURL, "/"),
+		token:              cfg.Token,
+		repos:              cfg.Repos,
+		includePrereleases: cfg.IncludePrereleases,
+		includeDrafts:      cfg.IncludeDrafts,
+		maxAge:             maxAge,
+		now:                time.Now,
+	}, nil
+}
+
+func (s *githubSource) Name() string {
+	return s.name
+}
+
+// Fetch returns releases published within maxAge across all repositories.
+// A failing repository doesn't hide the releases of the others.
+func (s *githubSource) Fetch(ctx context.Context) ([]Item, error) {
+	var items []Item
+	var errs []error
+	for _, repo := range s.repos {
+		releases, err := s.fetchReleases(ctx, repo)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", repo, err))
+			continue
+		}
+		items = append(items, s.newReleases(ctx, repo, releases)...)
+	}
+	return items, errors.Join(errs...)
+}
+
+func (s *githubSource) fetchReleases(ctx context.Context, repo string) ([]githubRelease, error) {
+	releasesURL := fmt.Sprintf("%s/repos/%s/releases?per_page=20", s.apiURL, repo)
+	req, err := http.NewRequestWithContext(ctx, "GET", releasesURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make NewRequest: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var releases []githubRelease
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+	return releases, nil
+}
+
+// newReleases filters releases down to the ones worth announcing and maps them to Items
+func (s *githubSource) newReleases(ctx context.Context, repo string, releases []githubRelease) []Item {
+	cutoff := s.now().Add(-s.maxAge)
+
+	var items []Item
+	for _, release := range releases {
+		if release.Draft && !s.includeDrafts {
+			recordDropped(ctx, dropDraft)
+			continue
+		}
+		if release.Prerelease && !s.includePrereleases {
+			recordDropped(ctx, dropPrerelease)
+			continue
+		}
+		// Drafts have no publish date yet
+		published := release.PublishedAt
+		if published.IsZero() {
+			published = release.CreatedAt
+		}
+		if published.Before(cutoff) {
+			recordDropped(ctx, dropTooOld)
+			continue
+		}
+
+		title := repo + " " + release.TagName
+		if release.Name != "" && release.Name != release.TagName {
+			title += ": " + release.Name
+		}
+		items = append(items, Item{
+			Title:     title,
+			Link:      release.HTMLURL,
+			Published: published,
+		})
+	}
+	return items
+}
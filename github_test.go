@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testReleasesJSON = `[
+  {
+    "tag_name": "v1.12.0-rc.1",
+    "name": "v1.12.0-rc.1",
+    "html_url": "https://github.com/stretchr/testify/releases/tag/v1.12.0-rc.1",
+    "draft": false,
+    "prerelease": true,
+    "created_at": "2026-10-16T08:00:00Z",
+    "published_at": "2026-10-16T09:00:00Z"
+  },
+  {
+    "tag_name": "v1.11.2",
+    "name": "Bug fixes",
+    "html_url": "https://github.com/stretchr/testify/releases/tag/v1.11.2",
+    "draft": false,
+    "prerelease": false,
+    "created_at": "2026-10-16T01:00:00Z",
+    "published_at": "2026-10-16T02:00:00Z"
+  },
+  {
+    "tag_name": "v1.12.0",
+    "name": "",
+    "html_url": "https://github.com/stretchr/testify/releases/tag/untagged-1234",
+    "draft": true,
+    "prerelease": false,
+    "created_at": "2026-10-16T10:00:00Z",
+    "published_at": null
+  },
+  {
+    "tag_name": "v1.11.1",
+    "name": "v1.11.1",
+    "html_url": "https://github.com/stretchr/testify/releases/tag/v1.11.1",
+    "draft": false,
+    "prerelease": false,
+    "created_at": "2026-08-01T00:00:00Z",
+    "published_at": "2026-08-01T00:00:00Z"
+  }
+]`
+
+// newFakeGitHubAPI serves releases for stretchr/testify and 404s for anything else
+func newFakeGitHubAPI(t *testing.T, wantToken string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/vnd.github+json", r.Header.Get("Accept"))
+		if wantToken != "" {
+			assert.Equal(t, "Bearer "+wantToken, r.Header.Get("Authorization"))
+		} else {
+			assert.Empty(t, r.Header.Get("Authorization"))
+		}
+
+		if r.URL.Path != "/repos/stretchr/testify/releases" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testReleasesJSON))
+	}))
+}
+
+func newTestGitHubSource(t *testing.T, cfg SourceConfig) *githubSource {
+	source, err := newGitHubSource(cfg)
+	require.NoError(t, err)
+
+	github := source.(*githubSource)
+	github.now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	return github
+}
+
+func TestGitHubSource_Fetch(t *testing.T) {
+	t.Run("yields recent stable releases by default", func(t *testing.T) {
+		server := newFakeGitHubAPI(t, "")
+		defer server.Close()
+
+		source := newTestGitHubSource(t, SourceConfig{Type: "github", URL: server.URL, Repos: []string{"stretchr/testify"}})
+
+		ctx, dropped := withDropCounter(context.Background())
+		items, err := source.Fetch(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []DropCount{
+			{Reason: dropPrerelease, Count: 1},
+			{Reason: dropDraft, Count: 1},
+			{Reason: dropTooOld, Count: 1},
+		}, dropped.list())
+		assert.Equal(t, []Item{
+			{
+				Title:     "stretchr/testify v1.11.2: Bug fixes",
+				Link:      "https://github.com/stretchr/testify/releases/tag/v1.11.2",
+				Published: time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC),
+			},
+		}, items)
+	})
+
+	t.Run("includes pre-releases and drafts when enabled", func(t *testing.T) {
+		server := newFakeGitHubAPI(t, "ghp_test")
+		defer server.Close()
+
+		source := newTestGitHubSource(t, SourceConfig{
+			Type:               "github",
+			URL:                server.URL,
+			Repos:              []string{"stretchr/testify"},
+			Token:              "ghp_test",
+			IncludePrereleases: true,
+			IncludeDrafts:      true,
+		})
+
+		items, err := source.Fetch(context.Background())
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		assert.Equal(t, "stretchr/testify v1.12.0-rc.1", items[0].Title)
+		assert.Equal(t, "stretchr/testify v1.11.2: Bug fixes", items[1].Title)
+		assert.Equal(t, "stretchr/testify v1.12.0", items[2].Title)
+		assert.Equal(t, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), items[2].Published)
+	})
+
+	t.Run("widens the window with MaxAge", func(t *testing.T) {
+		server := newFakeGitHubAPI(t, "")
+		defer server.Close()
+
+		source := newTestGitHubSource(t, SourceConfig{Type: "github", URL: server.URL, Repos: []string{"stretchr/testify"}, MaxAge: 90 * 24 * time.Hour})
+
+		items, err := source.Fetch(context.Background())
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "stretchr/testify v1.11.1", items[1].Title)
+	})
+
+	t.Run("keeps releases of healthy repositories when one fails", func(t *testing.T) {
+		server := newFakeGitHubAPI(t, "")
+		defer server.Close()
+
+		source := newTestGitHubSource(t, SourceConfig{Type: "github", URL: server.URL, Repos: []string{"golang/nonexistent", "stretchr/testify"}})
+
+		items, err := source.Fetch(context.Background())
+		assert.EqualError(t, err, "golang/nonexistent: unexpected status code: 404")
+		require.Len(t, items, 1)
+		assert.Equal(t, "stretchr/testify v1.11.2: Bug fixes", items[0].Title)
+	})
+}
+
+func TestNewGitHubSource(t *testing.T) {
+	t.Run("defaults name, API URL and window", func(t *testing.T) {
+		source := newTestGitHubSource(t, SourceConfig{Type: "github", Repos: []string{"golang/go"}})
+
+		assert.Equal(t, "GitHub", source.Name())
+		assert.Equal(t, defaultGitHubAPIURL, source.apiURL)
+		assert.Equal(t, defaultGitHubMaxAge, source.maxAge)
+	})
+
+	t.Run("requires repositories", func(t *testing.T) {
+		_, err := newGitHubSource(SourceConfig{Type: "github"})
+		assert.EqualError(t, err, "github source requires at least one repository")
+	})
+
+	t.Run("rejects malformed repository names", func(t *testing.T) {
+		for _, repo := range []string{"golang", "/go", "golang/", "golang/go/extra"} {
+			_, err := newGitHubSource(SourceConfig{Type: "github", Repos: []string{repo}})
+			assert.EqualError(t, err, `invalid repository "`+repo+`": expected owner/repo`)
+		}
+	})
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// CategoryRule assigns items to a named category by title keywords or link domains
+type CategoryRule struct {
+	Name     string   `yaml:"name"`
+	Keywords []string `yaml:"keywords,omitempty"`
+	Domains  []string `yaml:"domains,omitempty"`
+}
+
+// categoryMatcher is a compiled CategoryRule
+type categoryMatcher struct {
+	name     string
+	keywords []*regexp.Regexp
+	domains  []string
+}
+
+func newCategoryMatcher(rule CategoryRule) (*categoryMatcher, error) {
+	if rule.Name == "" {
+		return nil, errors.New("category requires a name")
+	}
+	if len(rule.Keywords) == 0 && len(rule.Domains) == 0 {
+		return nil, fmt.Errorf("category %q requires keywords or domains", rule.Name)
+	}
+
+	m := &categoryMatcher{name: rule.Name}
+	for _, keyword := range rule.Keywords {
+		m.keywords = append(m.keywords, keywordPattern(keyword))
+	}
+	for _, domain := range rule.Domains {
+		m.domains = append(m.domains, strings.ToLower(strings.TrimPrefix(domain, "www.")))
+	}
+	return m, nil
+}
+
+// keywordPattern matches keyword case-insensitively. Ends made of ASCII letters or digits
+// must sit on a word boundary, so "Go" doesn't match "Google"; Japanese keywords, which
+// aren't separated by spaces, match anywhere.
+func keywordPattern(keyword string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(keyword)
+	if first, _ := utf8.DecodeRuneInString(keyword); isASCIIWordRune(first) {
+		pattern = `\b` + pattern
+	}
+	if last, _ := utf8.DecodeLastRuneInString(keyword); isASCIIWordRune(last) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile(`(?i)` + pattern)
+}
+
+func isASCIIWordRune(r rune) bool {
+	return r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
+
+func (m *categoryMatcher) matches(item Item) bool {
+	for _, keyword := range m.keywords {
+		if keyword.MatchString(item.Title) {
+			return true
+		}
+	}
+	return domainMatches(linkDomain(item.Link), m.domains)
+}
+
+// groupedFormatter implements Formatter by packing the items into as few messages as fit,
+// in sections with a heading each. Numbering continues across sections, and items that
+// don't fit into maxMessages are summarized as "+N more" at the end.
+type groupedFormatter struct {
+	items       itemRenderer
+	localizer   *Localizer
+	groupBy     string
+	categories  []*categoryMatcher
+	maxMessages int
+	maxLength   int
+}
+
+func newGroupedFormatter(cfg FormatConfig, items itemRenderer, l *Localizer, maxMessages int) (*groupedFormatter, error) {
+	f := &groupedFormatter{
+		items:       items,
+		localizer:   l,
+		groupBy:     cfg.GroupBy,
+		maxMessages: max(maxMessages, 1),
+		maxLength:   MaxMessageLength,
+	}
+
+	switch cfg.GroupBy {
+	case "source", "domain":
+	case "category":
+		if len(cfg.Categories) == 0 {
+			return nil, errors.New("group_by category requires at least one category")
+		}
+		for _, rule := range cfg.Categories {
+			m, err := newCategoryMatcher(rule)
+			if err != nil {
+				return nil, err
+			}
+			f.categories = append(f.categories, m)
+		}
+	default:
+		return nil, fmt.Errorf("unknown group_by %q, expected source, domain or category", cfg.GroupBy)
+	}
+	return f, nil
+}
+
+// section is a run of items shown under one heading
+type section struct {
+	name  string
+	items []Item
+}
+
+// sections groups the items, keeping their order within a section. Sections come in the
+// order they first appear, or in the order of the rules when grouping by category.
+func (f *groupedFormatter) sections(items []Item) []section {
+	var sections []section
+	index := make(map[string]int)
+	for _, item := range items {
+		name := f.sectionName(item)
+		i, ok := index[name]
+		if !ok {
+			i = len(sections)
+			index[name] = i
+			sections = append(sections, section{name: name})
+		}
+		sections[i].items = append(sections[i].items, item)
+	}
+
+	if f.groupBy == "category" {
+		rank := func(name string) int {
+			for i, m := range f.categories {
+				if m.name == name {
+					return i
+				}
+			}
+			return len(f.categories)
+		}
+		slices.SortStableFunc(sections, func(a, b section) int {
+			return rank(a.name) - rank(b.name)
+		})
+	}
+	return sections
+}
+
+func (f *groupedFormatter) sectionName(item Item) string {
+	var name string
+	switch f.groupBy {
+	case "source":
+		name = item.Source
+	case "domain":
+		name = linkDomain(item.Link)
+	case "category":
+		for _, m := range f.categories {
+			if m.matches(item) {
+				name = m.name
+				break
+			}
+		}
+	}
+	if name == "" {
+		return f.localizer.T(msgOtherSection)
+	}
+	return name
+}
+
+func (f *groupedFormatter) Format(d *Digest) ([]string, error) {
+	// The last message keeps room for the "+N more" line in case not everything fits
+	moreReserve := len("\n\n") + len(f.localizer.T(msgMoreItems, len(d.Items)))
+	packer := &messagePacker{maxMessages: f.maxMessages, maxLength: f.maxLength, reserve: moreReserve}
+
+	total := len(d.Items)
+	number, packed, rendered := 0, 0, 0
+	for _, sec := range f.sections(d.Items) {
+		heading := f.localizer.T(msgSectionHeading, sec.name)
+		for _, item := range sec.items {
+			number++
+			text, err := f.items.renderItem(item, number, total)
+			if err != nil {
+				return nil, err
+			}
+			if text = strings.TrimSpace(text); text == "" {
+				continue
+			}
+			rendered++
+			if packer.full {
+				continue
+			}
+			if packer.add(heading, text) {
+				packed++
+			}
+		}
+	}
+
+	if remaining := rendered - packed; remaining > 0 {
+		packer.current += "\n\n" + f.localizer.T(msgMoreItems, remaining)
+	}
+	return packer.finish(), nil
+}
+
+// messagePacker fills messages up to maxLength with blocks, repeating the section heading
+// when a section carries over into the next message
+type messagePacker struct {
+	maxMessages int
+	maxLength   int
+	// reserve is kept free in the last message
+	reserve int
+
+	messages       []string
+	current        string
+	currentHeading string
+	full           bool
+}
+
+// add appends block under heading and reports whether there was room for it
+func (p *messagePacker) add(heading, block string) bool {
+	part := block
+	if p.current == "" || heading != p.currentHeading {
+		part = heading + "\n" + block
+	}
+
+	limit := p.maxLength
+	if len(p.messages) == p.maxMessages-1 {
+		limit -= p.reserve
+	}
+	if p.current == "" {
+		p.current = truncateBytes(part, limit)
+		p.currentHeading = heading
+		return true
+	}
+	if candidate := p.current + "\n\n" + part; len(candidate) <= limit {
+		p.current = candidate
+		p.currentHeading = heading
+		return true
+	}
+
+	if len(p.messages) == p.maxMessages-1 {
+		p.full = true
+		return false
+	}
+	p.messages = append(p.messages, p.current)
+	p.current = ""
+	return p.add(heading, block)
+}
+
+func (p *messagePacker) finish() []string {
+	if p.current != "" {
+		p.messages = append(p.messages, p.current)
+	}
+	return p.messages
+}
+
+// truncateBytes shortens s to at most n bytes without splitting a character,
+// marking the cut with an ellipsis
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	const ellipsis = "…"
+	cut := max(n-len(ellipsis), 0)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + ellipsis
+}
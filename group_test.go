@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGroupedFormatter(t *testing.T, cfg FormatConfig, locale string) *groupedFormatter {
+	f, err := newGroupedFormatter(cfg, hackerNewsFormatter{}, newTestLocalizer(t, locale), maxBatchSize)
+	require.NoError(t, err)
+	return f
+}
+
+func TestGroupedFormatter_Format(t *testing.T) {
+	items := []Item{
+		{Title: "Go 1.27 is released", Link: "https://go.dev/blog/go1.27", Source: "HN"},
+		{Title: "Google ships a new TPU", Link: "https://www.blog.google/tpu", Source: "Lobsters"},
+		{Title: "OpenSSL 3.6 security advisory", Link: "https://openssl.org/news/secadv", Source: "HN"},
+		{Title: "生成AIの最新動向", Link: "https://example.jp/ai", Source: "はてブ"},
+		{Title: "Profiling with pprof", Link: "https://pkg.go.dev/net/http/pprof", Source: "Lobsters"},
+	}
+
+	t.Run("by source with numbering across sections", func(t *testing.T) {
+		f := newTestGroupedFormatter(t, FormatConfig{GroupBy: "source"}, "ja")
+
+		messages, err := f.Format(&Digest{Items: items})
+		require.NoError(t, err)
+		assert.Equal(t, []string{strings.Join([]string{
+			"【HN】\n1. Go 1.27 is released\nhttps://go.dev/blog/go1.27",
+			"2. OpenSSL 3.6 security advisory\nhttps://openssl.org/news/secadv",
+			"【Lobsters】\n3. Google ships a new TPU\nhttps://www.blog.google/tpu",
+			"4. Profiling with pprof\nhttps://pkg.go.dev/net/http/pprof",
+			"【はてブ】\n5. 生成AIの最新動向\nhttps://example.jp/ai",
+		}, "\n\n")}, messages)
+	})
+
+	t.Run("by domain", func(t *testing.T) {
+		f := newTestGroupedFormatter(t, FormatConfig{GroupBy: "domain"}, "en")
+
+		messages, err := f.Format(&Digest{Items: items[:2]})
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.True(t, strings.HasPrefix(messages[0], "[go.dev]\n1. Go 1.27 is released"))
+		assert.Contains(t, messages[0], "\n\n[blog.google]\n2. Google ships a new TPU")
+	})
+
+	t.Run("by category in rule order with the rest last", func(t *testing.T) {
+		f := newTestGroupedFormatter(t, FormatConfig{
+			GroupBy: "category",
+			Categories: []CategoryRule{
+				{Name: "AI", Keywords: []string{"生成AI", "LLM"}},
+				{Name: "Go", Keywords: []string{"Go", "pprof"}, Domains: []string{"go.dev"}},
+				{Name: "Security", Keywords: []string{"security", "CVE"}},
+			},
+		}, "ja")
+
+		sections := f.sections(items)
+		names := make([]string, len(sections))
+		for i, sec := range sections {
+			names[i] = sec.name
+		}
+		assert.Equal(t, []string{"AI", "Go", "Security", "その他"}, names)
+		assert.Len(t, sections[1].items, 2)
+		assert.Equal(t, "Google ships a new TPU", sections[3].items[0].Title)
+	})
+}
+
+func TestGroupedFormatter_Packing(t *testing.T) {
+	var items []Item
+	for i := range 40 {
+		source := "HN"
+		if i >= 25 {
+			source = "Lobsters"
+		}
+		items = append(items, Item{
+			Title:  fmt.Sprintf("Story %d %s", i+1, strings.Repeat("x", 60)),
+			Link:   fmt.Sprintf("https://example.com/%d", i+1),
+			Source: source,
+		})
+	}
+
+	f := newTestGroupedFormatter(t, FormatConfig{GroupBy: "source"}, "en")
+	f.maxMessages = 3
+	f.maxLength = 500
+
+	messages, err := f.Format(&Digest{Items: items})
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	for _, msg := range messages {
+		assert.LessOrEqual(t, len(msg), 500)
+	}
+	assert.True(t, strings.HasPrefix(messages[1], "[HN]\n"), "heading repeats when a section carries over")
+	assert.Contains(t, messages[0], "\n\n2. Story 2")
+
+	last := messages[2]
+	assert.Regexp(t, `\n\n\+\d+ more$`, last)
+	var shown int
+	for _, msg := range messages {
+		shown += strings.Count(msg, "https://example.com/")
+	}
+	assert.True(t, strings.HasSuffix(last, fmt.Sprintf("+%d more", 40-shown)))
+}
+
+func TestNewGroupedFormatter_Validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         FormatConfig
+		expectError string
+	}{
+		{"unknown grouping", FormatConfig{GroupBy: "author"}, `unknown group_by "author", expected source, domain or category`},
+		{"category without rules", FormatConfig{GroupBy: "category"}, "group_by category requires at least one category"},
+		{"rule without name", FormatConfig{GroupBy: "category", Categories: []CategoryRule{{Keywords: []string{"Go"}}}}, "category requires a name"},
+		{"rule without matchers", FormatConfig{GroupBy: "category", Categories: []CategoryRule{{Name: "Go"}}}, `category "Go" requires keywords or domains`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newGroupedFormatter(tt.cfg, hackerNewsFormatter{}, newTestLocalizer(t, "ja"), maxBatchSize)
+			assert.EqualError(t, err, tt.expectError)
+		})
+	}
+}
+
+func TestKeywordPattern(t *testing.T) {
+	assert.True(t, keywordPattern("Go").MatchString("Go 1.27 is out"))
+	assert.True(t, keywordPattern("go").MatchString("Why we chose Go."))
+	assert.False(t, keywordPattern("Go").MatchString("Google I/O recap"))
+	assert.True(t, keywordPattern("C++").MatchString("Modern C++ tips"))
+	assert.True(t, keywordPattern("生成AI").MatchString("最新の生成AIニュース"))
+}
+
+func TestTruncateBytes(t *testing.T) {
+	assert.Equal(t, "short", truncateBytes("short", 10))
+	assert.Equal(t, "abcdefg…", truncateBytes("abcdefghijklmnop", 10))
+	// Never splits a multi-byte character
+	assert.Equal(t, "日本…", truncateBytes("日本語のタイトル", 10))
+}
+
+func TestNewFormatter_GroupedWithinPushLimit(t *testing.T) {
+	var items []Item
+	for i := range 300 {
+		items = append(items, Item{
+			Title:  fmt.Sprintf("Story %d %s", i+1, strings.Repeat("y", 80)),
+			Link:   fmt.Sprintf("https://example.com/%d", i+1),
+			Source: fmt.Sprintf("feed-%d", i%7),
+		})
+	}
+	cfg := &Config{Format: FormatConfig{GroupBy: "source", SummaryHeader: true, Header: "{{len .Items}} stories"}}
+
+	formatter, err := newFormatter(cfg, newTestLocalizer(t, "ja"))
+	require.NoError(t, err)
+	messages, err := formatter.Format(&Digest{Items: items})
+	require.NoError(t, err)
+
+	assert.Len(t, messages, maxBatchSize)
+	assert.Equal(t, "300 stories", messages[1])
+	assert.Regexp(t, `ほか\d+件$`, messages[maxBatchSize-1])
+	for _, msg := range messages {
+		assert.LessOrEqual(t, len(msg), MaxMessageLength)
+	}
+}
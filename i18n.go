@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultLocale is used for targets that don't pick one; most readers are Japanese
+const defaultLocale = "ja"
+
+// Message keys of the catalog
+const (
+	msgDigestHeader   = "digest.header"
+	msgDigestSources  = "digest.sources"
+	msgDigestFooter   = "digest.footer"
+	msgMorning        = "part.morning"
+	msgAfternoon      = "part.afternoon"
+	msgEvening        = "part.evening"
+	msgJustNow        = "reltime.just_now"
+	msgMinutesAgo     = "reltime.minutes"
+	msgHoursAgo       = "reltime.hours"
+	msgDaysAgo        = "reltime.days"
+	msgTestMessage    = "send_test.message"
+	msgSectionHeading = "section.heading"
+	msgOtherSection   = "section.other"
+	msgMoreItems      = "section.more"
+	msgAlsoSource     = "also.one"
+	msgAlsoSources    = "also.other"
+	msgReadingTime    = "reading_time"
+)
+
+// catalogs holds the user-facing strings per locale as fmt format strings.
+// Drop reasons are looked up as "drop." followed by the reason.
+var catalogs = map[string]map[string]string{
+	"ja": {
+		msgDigestHeader:          "%[1]s %[2]sのニュース: %[3]d件",
+		msgDigestSources:         " (%s)",
+		msgDigestFooter:          "除外: %d件 (%s)",
+		msgMorning:               "朝",
+		msgAfternoon:             "昼",
+		msgEvening:               "夜",
+		msgJustNow:               "たった今",
+		msgMinutesAgo:            "%d分前",
+		msgHoursAgo:              "%d時間前",
+		msgDaysAgo:               "%d日前",
+		msgTestMessage:           "imakoko: テストメッセージです",
+		msgSectionHeading:        "【%s】",
+		msgOtherSection:          "その他",
+		msgMoreItems:             "ほか%d件",
+		msgAlsoSource:            "ほか%dソースでも掲載",
+		msgAlsoSources:           "ほか%dソースでも掲載",
+		msgReadingTime:           "約%d分",
+		"drop." + dropStickied:   "固定投稿",
+		"drop." + dropNSFW:       "NSFW",
+		"drop." + dropLowScore:   "スコア不足",
+		"drop." + dropDraft:      "下書き",
+		"drop." + dropPrerelease: "プレリリース",
+		"drop." + dropTooOld:     "古い",
+		"drop." + dropDuplicate:  "重複",
+		"drop." + dropSimilar:    "類似記事",
+		"drop." + dropRankedOut:  "上位圏外",
+		"drop." + dropLanguage:   "対象外の言語",
+		"drop." + dropDeadLink:   "リンク切れ",
+	},
+	"en": {
+		msgDigestHeader:          "%[2]s news for %[1]s: %[3]d items",
+		msgDigestSources:         " (%s)",
+		msgDigestFooter:          "Filtered out: %d (%s)",
+		msgMorning:               "Morning",
+		msgAfternoon:             "Afternoon",
+		msgEvening:               "Evening",
+		msgJustNow:               "just now",
+		msgMinutesAgo:            "%dm ago",
+		msgHoursAgo:              "%dh ago",
+		msgDaysAgo:               "%dd ago",
+		msgTestMessage:           "imakoko: test message",
+		msgSectionHeading:        "[%s]",
+		msgOtherSection:          "Other",
+		msgMoreItems:             "+%d more",
+		msgAlsoSource:            "also: %d more source",
+		msgAlsoSources:           "also: %d more sources",
+		msgReadingTime:           "~%d min read",
+		"drop." + dropStickied:   "stickied",
+		"drop." + dropNSFW:       "NSFW",
+		"drop." + dropLowScore:   "low score",
+		"drop." + dropDraft:      "draft",
+		"drop." + dropPrerelease: "pre-release",
+		"drop." + dropTooOld:     "too old",
+		"drop." + dropDuplicate:  "duplicate",
+		"drop." + dropSimilar:    "same story",
+		"drop." + dropRankedOut:  "below the top",
+		"drop." + dropLanguage:   "other language",
+		"drop." + dropDeadLink:   "dead link",
+	},
+}
+
+// japaneseWeekdays are the one-character weekday names used in Japanese dates
+var japaneseWeekdays = [...]string{"日", "月", "火", "水", "木", "金", "土"}
+
+// supportedLocales lists the catalog locales in a stable order for error messages
+func supportedLocales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// validateLocale checks that locale has a catalog; empty means the default
+func validateLocale(locale string) error {
+	if locale == "" {
+		return nil
+	}
+	if _, ok := catalogs[locale]; !ok {
+		return fmt.Errorf("locale %q is not supported, use one of: %s", locale, strings.Join(supportedLocales(), ", "))
+	}
+	return nil
+}
+
+// Localizer renders user-facing strings in one locale
+type Localizer struct {
+	locale  string
+	catalog map[string]string
+}
+
+// NewLocalizer returns the Localizer for locale, or for the default locale when it is empty
+func NewLocalizer(locale string) (*Localizer, error) {
+	if locale == "" {
+		locale = defaultLocale
+	}
+	if err := validateLocale(locale); err != nil {
+		return nil, err
+	}
+	return &Localizer{locale: locale, catalog: catalogs[locale]}, nil
+}
+
+// Locale returns the locale the Localizer renders
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// T formats the catalog entry for key with args. Unknown keys render as the key itself,
+// so a missing translation shows up in the output instead of failing the delivery.
+func (l *Localizer) T(key string, args ...any) string {
+	format, ok := l.catalog[key]
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Date formats t as a short date, "10月16日(金)" in Japanese and "Fri, Oct 16" in English
+func (l *Localizer) Date(t time.Time) string {
+	if l.locale == "ja" {
+		return fmt.Sprintf("%d月%d日(%s)", t.Month(), t.Day(), japaneseWeekdays[t.Weekday()])
+	}
+	return t.Format("Mon, Jan 2")
+}
+
+// PartOfDay names the time of day of t, such as 朝 or Morning
+func (l *Localizer) PartOfDay(t time.Time) string {
+	switch h := t.Hour(); {
+	case h >= 4 && h < 11:
+		return l.T(msgMorning)
+	case h >= 11 && h < 17:
+		return l.T(msgAfternoon)
+	default:
+		return l.T(msgEvening)
+	}
+}
+
+// RelativeTime describes how long before now t was, such as "3時間前" or "3h ago"
+func (l *Localizer) RelativeTime(t, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return l.T(msgJustNow)
+	case d < time.Hour:
+		return l.T(msgMinutesAgo, int(d.Minutes()))
+	case d < 24*time.Hour:
+		return l.T(msgHoursAgo, int(d.Hours()))
+	default:
+		return l.T(msgDaysAgo, int(d.Hours()/24))
+	}
+}
+
+// AlsoSources notes that n more sources covered the same story
+func (l *Localizer) AlsoSources(n int) string {
+	if n == 1 {
+		return l.T(msgAlsoSource, n)
+	}
+	return l.T(msgAlsoSources, n)
+}
+
+// ReadingTime renders an estimate of minutes to read, or nothing when there is no estimate
+func (l *Localizer) ReadingTime(minutes int) string {
+	if minutes <= 0 {
+		return ""
+	}
+	return l.T(msgReadingTime, minutes)
+}
+
+// DropReason labels why items were filtered out, falling back to the reason key
+func (l *Localizer) DropReason(reason string) string {
+	if label, ok := l.catalog["drop."+reason]; ok {
+		return label
+	}
+	return reason
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogs_Complete(t *testing.T) {
+	for locale, catalog := range catalogs {
+		for key := range catalogs[defaultLocale] {
+			assert.Contains(t, catalog, key, "locale %s is missing %s", locale, key)
+		}
+		assert.Len(t, catalog, len(catalogs[defaultLocale]), "locale %s has keys the default locale lacks", locale)
+	}
+}
+
+func TestNewLocalizer(t *testing.T) {
+	l, err := NewLocalizer("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultLocale, l.Locale())
+
+	_, err = NewLocalizer("fr")
+	assert.EqualError(t, err, `locale "fr" is not supported, use one of: en, ja`)
+}
+
+func TestLocalizer(t *testing.T) {
+	ja, en := newTestLocalizer(t, "ja"), newTestLocalizer(t, "en")
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Date", func(t *testing.T) {
+		assert.Equal(t, "10月16日(金)", ja.Date(now))
+		assert.Equal(t, "1月4日(日)", ja.Date(time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, "Fri, Oct 16", en.Date(now))
+	})
+
+	t.Run("PartOfDay", func(t *testing.T) {
+		assert.Equal(t, "朝", ja.PartOfDay(now.Add(-5*time.Hour)))
+		assert.Equal(t, "昼", ja.PartOfDay(now))
+		assert.Equal(t, "Evening", en.PartOfDay(now.Add(10*time.Hour)))
+	})
+
+	t.Run("RelativeTime", func(t *testing.T) {
+		assert.Equal(t, "たった今", ja.RelativeTime(now.Add(-30*time.Second), now))
+		assert.Equal(t, "45分前", ja.RelativeTime(now.Add(-45*time.Minute), now))
+		assert.Equal(t, "23時間前", ja.RelativeTime(now.Add(-23*time.Hour), now))
+		assert.Equal(t, "3日前", ja.RelativeTime(now.Add(-75*time.Hour), now))
+		assert.Equal(t, "just now", en.RelativeTime(now.Add(-30*time.Second), now))
+		assert.Equal(t, "45m ago", en.RelativeTime(now.Add(-45*time.Minute), now))
+		assert.Equal(t, "3d ago", en.RelativeTime(now.Add(-75*time.Hour), now))
+		assert.Equal(t, "", en.RelativeTime(time.Time{}, now))
+	})
+
+	t.Run("T falls back to the key", func(t *testing.T) {
+		assert.Equal(t, "imakoko: テストメッセージです", ja.T(msgTestMessage))
+		assert.Equal(t, "no.such.key", en.T("no.such.key"))
+		assert.Equal(t, "スコア不足", ja.DropReason(dropLowScore))
+		assert.Equal(t, "unlisted", en.DropReason("unlisted"))
+	})
+}
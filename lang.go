@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Languages the detector tells apart, as ISO 639-1 codes
+const (
+	langEnglish  = "en"
+	langJapanese = "ja"
+	langChinese  = "zh"
+	langKorean   = "ko"
+	langGerman   = "de"
+	langFrench   = "fr"
+	langSpanish  = "es"
+)
+
+// supportedLanguages are the codes accepted in languages settings
+var supportedLanguages = []string{langEnglish, langJapanese, langChinese, langKorean, langGerman, langFrench, langSpanish}
+
+func validateLanguages(languages []string) error {
+	for _, lang := range languages {
+		if !slices.Contains(supportedLanguages, lang) {
+			return fmt.Errorf("language %q is not supported, use one of: %s", lang, strings.Join(supportedLanguages, ", "))
+		}
+	}
+	return nil
+}
+
+// trigramProfiles are the most frequent letter trigrams of the Latin-script languages,
+// most frequent first, with spaces marking word boundaries
+var trigramProfiles = map[string][]string{
+	langEnglish: {
+		" th", "the", "he ", "ing", " an", "nd ", "and", "ng ", " to", "to ", " of", "of ", "ed ", "ion",
+		" in", "er ", "is ", " is", "es ", "for", " fo", "or ", "on ", "at ", "tio", "ent", "hat", "tha",
+		"ith", "wit", " wi", "you", " yo", "ers", " on", "ly ", "how", " ho", "ow ", "ew ", "new", " ne",
+	},
+	langGerman: {
+		"en ", "er ", "ch ", "der", "die", "ie ", "ein", "sch", "ich", "nd ", "und", " un", " di", " de",
+		"den", "cht", "ung", "gen", " ei", "te ", "eit", "ter", " ge", "ver", " ve", " zu", "ist", "ine",
+		"mit", " mi", "auf", " au", "das", " da", "für", " fü", "ür ", "ert", "lic", "ach", "sie", "auc",
+	},
+	langFrench: {
+		"es ", "de ", " de", " le", "le ", "ent", "la ", " la", "les", " et", "et ", "ion", " pa", "re ",
+		"nt ", "que", "ue ", " qu", " co", "our", "des", " un", "une", "ne ", "eur", "ais", " po", "pou",
+		"ans", " da", "dan", "ur ", " l'", " d'", "est", " es", "tio", "men", "ié ", "és ", " à ", "pas",
+	},
+	langSpanish: {
+		"de ", " de", "os ", " la", "la ", "el ", " el", "en ", "es ", "ión", "ón ", " co", "ue ", "que",
+		" qu", "as ", "ent", " en", "ado", "los", " lo", "del", "ara", " pa", "par", "nte", "con", " y ",
+		"una", " un", "ra ", "por", " po", "ien", "ció", "las", "sta", "ame", "ño ", "más", "ar ", "cia",
+	},
+}
+
+// Han-only text is told apart by characters only one of the languages writes: simplified
+// and traditional forms and particles for Chinese, Japanese shinjitai forms and kokuji
+var (
+	chineseHan  = []rune("们們这這个個么麼没沒说說吗嗎呢发發进还对對从從门问间车东长开关關见现为与與将將让讓给")
+	japaneseHan = []rune("発広様実気売読図対経済駅円込働畑峠辺変関県払戦転伝単歳")
+)
+
+// detectLanguage returns the language text is most likely written in, or "" when it
+// can't tell. The script decides first: any kana means Japanese, Hangul means Korean and
+// other Han text is Chinese unless it uses Japanese-only forms. Latin text is matched
+// against trigram profiles, English unless another language clearly fits better.
+func detectLanguage(text string) string {
+	var kana, hangul, han, latin int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	switch {
+	case hangul > 0 && hangul >= kana:
+		return langKorean
+	case kana > 0:
+		return langJapanese
+	case han > 0:
+		return detectHanLanguage(text)
+	case latin > 0:
+		return detectLatinLanguage(text)
+	}
+	return ""
+}
+
+// detectHanLanguage tells Chinese from Japanese written in kanji only, such as headlines.
+// Without telltale characters it goes with Japanese, the language of most such titles here.
+func detectHanLanguage(text string) string {
+	var chinese, japanese int
+	for _, r := range text {
+		if slices.Contains(chineseHan, r) {
+			chinese++
+		}
+		if slices.Contains(japaneseHan, r) {
+			japanese++
+		}
+	}
+	if chinese > japanese {
+		return langChinese
+	}
+	return langJapanese
+}
+
+func detectLatinLanguage(text string) string {
+	padded := " " + strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}), " ") + " "
+	grams := []rune(padded)
+
+	scores := make(map[string]int, len(trigramProfiles))
+	for i := 0; i+3 <= len(grams); i++ {
+		gram := string(grams[i : i+3])
+		for lang, profile := range trigramProfiles {
+			// Frequent trigrams weigh more
+			if rank := slices.Index(profile, gram); rank >= 0 {
+				scores[lang] += len(profile) - rank
+			}
+		}
+	}
+
+	best := langEnglish
+	for _, lang := range []string{langGerman, langFrench, langSpanish} {
+		// Short titles share many trigrams, so English only gives way to a clear winner
+		if scores[lang] > scores[best] && scores[lang]*2 > scores[langEnglish]*3 {
+			best = lang
+		}
+	}
+	return best
+}
+
+// tagLanguages sets the Lang of every item from its title
+func tagLanguages(items []Item) {
+	for i := range items {
+		items[i].Lang = detectLanguage(items[i].Title)
+	}
+}
+
+// filterLanguages keeps the items in one of languages, recording the others as dropped.
+// Items whose language is unknown are kept; no languages keeps everything.
+func filterLanguages(ctx context.Context, items []Item, languages []string) []Item {
+	if len(languages) == 0 {
+		return items
+	}
+	kept := make([]Item, 0, len(items))
+	for _, item := range items {
+		if item.Lang != "" && !slices.Contains(languages, item.Lang) {
+			recordDropped(ctx, dropLanguage)
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+// forLanguages returns a copy of the digest keeping only the items in one of languages,
+// with the others counted among its dropped items
+func (d *Digest) forLanguages(languages []string) *Digest {
+	if len(languages) == 0 {
+		return d
+	}
+	ctx, dropped := withDropCounter(context.Background())
+	for _, c := range d.Dropped {
+		dropped.add(c.Reason, c.Count)
+	}
+	filtered := *d
+	filtered.Items = filterLanguages(ctx, d.Items, languages)
+	filtered.Dropped = dropped.list()
+	return &filtered
+}
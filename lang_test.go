@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"Show HN: I built a tiny database in Go", langEnglish},
+		{"The state of the Linux kernel in 2026", langEnglish},
+		{"Rust 1.90", langEnglish},
+		{"Rustで書かれた新しいOSが公開", langJapanese},
+		{"首相、経済対策を発表", langJapanese},
+		{"東京都知事選", langJapanese},
+		{"苹果发布新款芯片，性能提升了一倍", langChinese},
+		{"這是我們的新專案", langChinese},
+		{"서울 날씨 오늘 맑음", langKorean},
+		{"Die Zukunft der Programmiersprachen und warum sie sich ändern", langGerman},
+		{"Les nouveautés de la version de Python pour les développeurs", langFrench},
+		{"Cómo funciona la memoria de los programas en el sistema", langSpanish},
+		{"2026-10-16", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, detectLanguage(tt.text), tt.text)
+	}
+}
+
+func TestFilterLanguages(t *testing.T) {
+	items := []Item{
+		{Title: "Go 1.27 released"},
+		{Title: "東京都が新アプリ"},
+		{Title: "서울 날씨"},
+		{Title: "2026"},
+	}
+	tagLanguages(items)
+	ctx, dropped := withDropCounter(context.Background())
+
+	kept := filterLanguages(ctx, items, []string{langJapanese, langEnglish})
+
+	assert.Equal(t, []Item{
+		{Title: "Go 1.27 released", Lang: langEnglish},
+		{Title: "東京都が新アプリ", Lang: langJapanese},
+		{Title: "2026"},
+	}, kept)
+	assert.Equal(t, []DropCount{{Reason: dropLanguage, Count: 1}}, dropped.list())
+	assert.Len(t, filterLanguages(ctx, items, nil), 4)
+}
+
+func TestDigest_ForLanguages(t *testing.T) {
+	d := &Digest{
+		Items:   []Item{{Title: "Go 1.27 released", Lang: langEnglish}, {Title: "東京都が新アプリ", Lang: langJapanese}},
+		Dropped: []DropCount{{Reason: dropLanguage, Count: 2}, {Reason: dropDuplicate, Count: 1}},
+	}
+
+	filtered := d.forLanguages([]string{langJapanese})
+
+	assert.Equal(t, []Item{{Title: "東京都が新アプリ", Lang: langJapanese}}, filtered.Items)
+	assert.Equal(t, []DropCount{{Reason: dropLanguage, Count: 3}, {Reason: dropDuplicate, Count: 1}}, filtered.Dropped)
+	assert.Len(t, d.Items, 2, "the digest is left as it was")
+	assert.Same(t, d, d.forLanguages(nil))
+}
+
+func TestDeliverDigest_Languages(t *testing.T) {
+	const groupID = "C0123456789abcdef0123456789abcdef"
+	cfg := &Config{
+		DryRun:       true,
+		TargetUserID: testUserID,
+		Targets:      []TargetConfig{{UserID: groupID, Languages: []string{langJapanese}}},
+	}
+	d := &Digest{Items: []Item{
+		{Title: "Go 1.27 released", Link: "https://go.dev/blog/go1.27", Lang: langEnglish},
+		{Title: "東京都が新アプリ", Link: "https://example.jp/1", Lang: langJapanese},
+	}}
+	var out bytes.Buffer
+
+	sent, err := deliverDigest(context.Background(), cfg, d, &out)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, sent, "two items for the user and only the Japanese one for the group")
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,6 +12,9 @@ import (
 // LINE API message character limit
 const MaxMessageLength = 5000
 
+// Maximum number of messages LINE accepts in a single push
+const maxBatchSize = 5
+
 // Maximum size for error response body
 const maxErrorResponseSize = 4 * 1024 // 4KB
 
@@ -52,26 +56,34 @@ type LineContent struct {
 	Text string `json:"text"`
 }
 
-func sendBatchLineMessage(sender MessageSender, messages []string) error {
-	const batchSize = 5
-
-	for i := 0; i < len(messages); i += batchSize {
-		end := min(i+batchSize, len(messages))
+// sendBatchLineMessage pushes the messages in batches of maxBatchSize, stopping at the first
+// batch that fails, which is logged with its range
+func sendBatchLineMessage(ctx context.Context, sender MessageSender, messages []string) error {
+	for i := 0; i < len(messages); i += maxBatchSize {
+		end := min(i+maxBatchSize, len(messages))
 
 		batch := messages[i:end]
+		batchRange := fmt.Sprintf("%d-%d", i+1, end)
 		if err := sender.Send(batch); err != nil {
-			return fmt.Errorf("failed to send batch %d-%d: %w", i+1, end, err)
+			loggerFrom(ctx).Error("failed to send batch", "batch", batchRange, "messages", len(batch), "error", err)
+			return fmt.Errorf("failed to send batch %s: %w", batchRange, err)
 		}
+		loggerFrom(ctx).Debug("sent batch", "batch", batchRange, "messages", len(batch))
 	}
 
 	return nil
 }
 
-func sendLineMessage(httpClient *http.Client, apiURL string, accessToken string, messages []string, sendTo string) error {
+// buildLinePayload validates messages against LINE's limits and returns the JSON push request body
+func buildLinePayload(messages []string, sendTo string) ([]byte, error) {
+	if len(messages) > maxBatchSize {
+		return nil, fmt.Errorf("batch has %d messages, LINE accepts at most %d per push", len(messages), maxBatchSize)
+	}
+
 	contents := make([]LineContent, len(messages))
 	for i, msg := range messages {
 		if len(msg) > MaxMessageLength {
-			return fmt.Errorf("message %d exceeds LINE's %d character limit (has %d characters)", i+1, MaxMessageLength, len(msg))
+			return nil, fmt.Errorf("message %d exceeds LINE's %d character limit (has %d characters)", i+1, MaxMessageLength, len(msg))
 		}
 		contents[i] = LineContent{
 			Type: "text",
@@ -86,7 +98,15 @@ func sendLineMessage(httpClient *http.Client, apiURL string, accessToken string,
 
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return jsonData, nil
+}
+
+func sendLineMessage(httpClient *http.Client, apiURL string, accessToken string, messages []string, sendTo string) error {
+	jsonData, err := buildLinePayload(messages, sendTo)
+	if err != nil {
+		return err
 	}
 
 	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -116,7 +117,7 @@ func TestSendBatchLineMessage(t *testing.T) {
 			}
 
 			// Execute
-			err := sendBatchLineMessage(mock, messages)
+			err := sendBatchLineMessage(context.Background(), mock, messages)
 
 			// Assertions
 			if tt.shouldError {
@@ -145,7 +146,7 @@ func TestSendBatchLineMessage(t *testing.T) {
 func TestSendBatchLineMessage_EmptyMessages(t *testing.T) {
 	mock := &mockMessageSender{}
 
-	err := sendBatchLineMessage(mock, []string{})
+	err := sendBatchLineMessage(context.Background(), mock, []string{})
 
 	require.NoError(t, err)
 	assert.Equal(t, 0, len(mock.sentBatches), "should not send with empty messages")
@@ -331,3 +332,17 @@ func TestSendLineMessage_MessageLengthValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildLinePayload(t *testing.T) {
+	t.Run("marshals the push request", func(t *testing.T) {
+		payload, err := buildLinePayload([]string{"Hello"}, "U123456")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"to":"U123456","messages":[{"type":"text","text":"Hello"}]}`, string(payload))
+	})
+
+	t.Run("rejects more than 5 messages", func(t *testing.T) {
+		payload, err := buildLinePayload([]string{"1", "2", "3", "4", "5", "6"}, "U123456")
+		assert.Nil(t, payload)
+		assert.EqualError(t, err, "batch has 6 messages, LINE accepts at most 5 per push")
+	})
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Link check defaults, used for settings left at zero
+const (
+	defaultLinkCheckTimeout     = 10 * time.Second
+	defaultLinkCheckConcurrency = 8
+)
+
+// What to do with items whose link is broken
+const (
+	brokenLinkDrop     = "drop"
+	brokenLinkAnnotate = "annotate"
+)
+
+// LinkCheckConfig enables checking the item links before sending. Zero values take
+// the defaults.
+type LinkCheckConfig struct {
+	// Broken is what happens to items whose link is dead: drop (default) or annotate
+	Broken string `yaml:"broken"`
+	// Timeout bounds checking a single link
+	Timeout time.Duration `yaml:"timeout"`
+	// Concurrency is how many links are checked at once
+	Concurrency int `yaml:"concurrency"`
+}
+
+func (c LinkCheckConfig) validate() error {
+	var errs []error
+	if c.Broken != "" && c.Broken != brokenLinkDrop && c.Broken != brokenLinkAnnotate {
+		errs = append(errs, fmt.Errorf("link_check.broken %q must be drop or annotate", c.Broken))
+	}
+	if c.Timeout < 0 || c.Concurrency < 0 {
+		errs = append(errs, errors.New("link_check limits must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// withDefaults fills in the settings left at zero
+func (c LinkCheckConfig) withDefaults() LinkCheckConfig {
+	if c.Broken == "" {
+		c.Broken = brokenLinkDrop
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultLinkCheckTimeout
+	}
+	if c.Concurrency == 0 {
+		c.Concurrency = defaultLinkCheckConcurrency
+	}
+	return c
+}
+
+// linkCheckHTTPClient checks links; callers bound each request with a context
+var linkCheckHTTPClient = &http.Client{}
+
+// checkLinks checks every link, dropping the items whose link
+// is dead, recorded as dropped, or marking them LinkBroken when annotating
+func checkLinks(ctx context.Context, items []Item, cfg LinkCheckConfig) []Item {
+	cfg = cfg.withDefaults()
+
+	broken := make([]bool, len(items))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, cfg.Concurrency)
+	for i := range items {
+		if items[i].Link == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if err := checkLink(ctx, linkCheckHTTPClient, items[i].Link, cfg.Timeout); err != nil {
+				loggerFrom(ctx).Warn("broken link", "source", items[i].Source, "link", items[i].Link, "error", err)
+				broken[i] = true
+			}
+		}()
+	}
+	wg.Wait()
+
+	kept := make([]Item, 0, len(items))
+	for i, item := range items {
+		if broken[i] {
+			if cfg.Broken == brokenLinkDrop {
+				recordDropped(ctx, dropDeadLink)
+				continue
+			}
+			item.LinkBroken = true
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+// checkLink reports an error when link is dead. It asks with HEAD first and retries
+// with GET when the server won't answer HEAD properly. Sites that refuse bots or rate
+// limit us (401, 403, 429) and links that time out are given the benefit of the doubt.
+func checkLink(ctx context.Context, client *http.Client, link string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	status, err := linkStatus(ctx, client, "HEAD", link)
+	if err == nil && (status < 400 || status == http.StatusNotFound || status == http.StatusGone) {
+		return brokenStatus(status)
+	}
+	status, err = linkStatus(ctx, client, "GET", link)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil
+		}
+		return err
+	}
+	return brokenStatus(status)
+}
+
+func linkStatus(ctx context.Context, client *http.Client, method, link string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make NewRequest: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func brokenStatus(status int) error {
+	switch {
+	case status < 400, status == http.StatusUnauthorized, status == http.StatusForbidden, status == http.StatusTooManyRequests:
+		return nil
+	}
+	return fmt.Errorf("unexpected status code: %d", status)
+}
+
+// markPaywalled marks the items linking to one of the paywalled domains
+func markPaywalled(items []Item, domains []string) {
+	for i := range items {
+		items[i].Paywalled = domainMatches(linkDomain(items[i].Link), domains)
+	}
+}
+
+// domainMatches reports whether domain is one of domains or a subdomain of one
+func domainMatches(domain string, domains []string) bool {
+	domain = strings.ToLower(domain)
+	if domain == "" {
+		return false
+	}
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(d, "www."))
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
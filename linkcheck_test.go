@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLinkCheckServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/no-head":
+			// Some servers only answer GET
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/bot-wall":
+			w.WriteHeader(http.StatusForbidden)
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheckLink(t *testing.T) {
+	server := newLinkCheckServer(t)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/ok", ""},
+		{"/no-head", ""},
+		{"/bot-wall", ""},
+		{"/slow", ""},
+		{"/missing", "unexpected status code: 404"},
+		{"/gone", "unexpected status code: 410"},
+		{"/error", "unexpected status code: 500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := checkLink(context.Background(), server.Client(), server.URL+tt.path, 50*time.Millisecond)
+			if tt.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expected)
+			}
+		})
+	}
+
+	t.Run("unreachable hosts are broken", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		assert.Error(t, checkLink(context.Background(), http.DefaultClient, closed.URL, time.Second))
+	})
+}
+
+func TestCheckLinks(t *testing.T) {
+	server := newLinkCheckServer(t)
+	newItems := func() []Item {
+		return []Item{
+			{Title: "Fine", Link: server.URL + "/ok"},
+			{Title: "Gone", Link: server.URL + "/gone", Source: "Lobsters"},
+			{Title: "Paywalled", Link: "https://www.nikkei.com/article/1"},
+			{Title: "Ask HN"},
+		}
+	}
+	original := linkCheckHTTPClient
+	defer func() { linkCheckHTTPClient = original }()
+
+	t.Run("drops dead links", func(t *testing.T) {
+		linkCheckHTTPClient = server.Client()
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, LogConfig{Format: "json"})
+		require.NoError(t, err)
+		ctx, dropped := withDropCounter(withLogger(context.Background(), logger))
+
+		items := checkLinks(ctx, newItems()[:2], LinkCheckConfig{})
+
+		assert.Equal(t, []Item{{Title: "Fine", Link: server.URL + "/ok"}}, items)
+		assert.Equal(t, []DropCount{{Reason: dropDeadLink, Count: 1}}, dropped.list())
+		lines := logLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "broken link", lines[0]["msg"])
+		assert.Equal(t, "Lobsters", lines[0]["source"])
+	})
+
+	t.Run("annotates dead links", func(t *testing.T) {
+		// The paywalled article is served by the test server too
+		linkCheckHTTPClient = &http.Client{Transport: rewriteTransport{server: server}}
+
+		items := checkLinks(context.Background(), newItems(), LinkCheckConfig{Broken: brokenLinkAnnotate})
+
+		require.Len(t, items, 4)
+		assert.False(t, items[0].LinkBroken)
+		assert.True(t, items[1].LinkBroken)
+		assert.False(t, items[2].LinkBroken)
+		assert.False(t, items[3].LinkBroken)
+	})
+}
+
+// rewriteTransport sends every request to the test server, the paywalled article to /ok
+type rewriteTransport struct {
+	server *httptest.Server
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "http"
+	req.URL.Host = t.server.Listener.Addr().String()
+	if req.URL.Path == "/article/1" {
+		req.URL.Path = "/ok"
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestMarkPaywalled(t *testing.T) {
+	items := []Item{
+		{Title: "Paywalled", Link: "https://asia.nikkei.com/article/1"},
+		{Title: "Free", Link: "https://example.com/free"},
+		{Title: "Ask HN"},
+	}
+
+	markPaywalled(items, []string{"nikkei.com"})
+
+	assert.True(t, items[0].Paywalled)
+	assert.False(t, items[1].Paywalled)
+	assert.False(t, items[2].Paywalled)
+}
+
+func TestDomainMatches(t *testing.T) {
+	domains := []string{"nikkei.com", "www.wsj.com"}
+
+	assert.True(t, domainMatches("nikkei.com", domains))
+	assert.True(t, domainMatches("asia.nikkei.com", domains))
+	assert.True(t, domainMatches("WSJ.com", domains))
+	assert.False(t, domainMatches("notnikkei.com", domains))
+	assert.False(t, domainMatches("", domains))
+}
+
+func TestFormatHackerNews_Markers(t *testing.T) {
+	items := []Item{
+		{Title: "Paywalled", Link: "https://www.nikkei.com/article/1", Paywalled: true},
+		{Title: "Broken", Link: "https://example.com/gone", LinkBroken: true},
+	}
+
+	assert.Equal(t, []string{
+		"1. 🔒 Paywalled\nhttps://www.nikkei.com/article/1",
+		"2. ⚠️ Broken\nhttps://example.com/gone",
+	}, FormatHackerNews(items))
+}
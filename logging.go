@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// LogConfig selects how log lines are written
+type LogConfig struct {
+	// Format is text (default) or json
+	Format string `yaml:"format"`
+	// Level is the least severe level written: debug, info (default), warn or error
+	Level string `yaml:"level"`
+}
+
+func (c LogConfig) validate() error {
+	var errs []error
+	if c.Format != "" && c.Format != "text" && c.Format != "json" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT %q must be text or json", c.Format))
+	}
+	if _, err := c.level(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (c LogConfig) level() (slog.Level, error) {
+	var level slog.Level
+	if c.Level == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return 0, fmt.Errorf("LOG_LEVEL %q must be debug, info, warn or error", c.Level)
+	}
+	return level, nil
+}
+
+// newLogger returns a logger writing to w in the configured format and level
+func newLogger(w io.Writer, cfg LogConfig) (*slog.Logger, error) {
+	level, err := cfg.level()
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.Format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return slog.New(slog.NewTextHandler(w, opts)), nil
+}
+
+// newRunID returns a random ID correlating the log lines of one run
+func newRunID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type loggerKey struct{}
+
+// withLogger returns a context carrying logger, usually one with attributes added
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// loggerFrom returns the logger of ctx, or the default logger when it carries none
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// beginRun gives the invocation of the named command its run ID and a logger following
+// LOG_FORMAT and LOG_LEVEL, which logs until startRun applies the loaded config. Invalid
+// settings fall back to text from info; validating the config reports them.
+func beginRun(inv *invocation, name string) {
+	cfg := LogConfig{Format: os.Getenv("LOG_FORMAT"), Level: os.Getenv("LOG_LEVEL")}
+	if cfg.validate() != nil {
+		cfg = LogConfig{}
+	}
+	logger, _ := newLogger(inv.stderr, cfg)
+	inv.command, inv.runID = name, newRunID()
+	inv.logger = logger.With("run_id", inv.runID, "command", name)
+}
+
+// startRun switches the invocation to logging as configured: every line carries the run's
+// ID and command. The returned context carries the logger.
+func startRun(inv *invocation, cfg *Config) (context.Context, error) {
+	logger, err := newLogger(inv.stderr, cfg.Log)
+	if err != nil {
+		return nil, err
+	}
+	inv.logger = logger.With("run_id", inv.runID, "command", inv.command)
+	inv.logger.Debug("loaded config", "config", cfg)
+	return withLogger(context.Background(), inv.logger), nil
+}
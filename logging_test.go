@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logLines decodes the JSON log lines written to buf
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestNewLogger(t *testing.T) {
+	t.Run("json at the configured level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, LogConfig{Format: "json", Level: "warn"})
+		require.NoError(t, err)
+
+		logger.Info("hidden")
+		logger.Warn("shown", "source", "HN")
+
+		lines := logLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "shown", lines[0]["msg"])
+		assert.Equal(t, "HN", lines[0]["source"])
+	})
+
+	t.Run("text by default", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, LogConfig{})
+		require.NoError(t, err)
+
+		logger.Debug("hidden")
+		logger.Info("shown", "target", testUserID)
+
+		assert.Contains(t, buf.String(), `level=INFO msg=shown target=`+testUserID)
+		assert.NotContains(t, buf.String(), "hidden")
+	})
+}
+
+func TestLogConfig_Validate(t *testing.T) {
+	require.NoError(t, LogConfig{Format: "json", Level: "debug"}.validate())
+	assert.EqualError(t, LogConfig{Format: "logfmt", Level: "verbose"}.validate(),
+		"LOG_FORMAT \"logfmt\" must be text or json\nLOG_LEVEL \"verbose\" must be debug, info, warn or error")
+}
+
+func TestConfig_LogValue(t *testing.T) {
+	cfg := &Config{
+		LineAccessToken: "secret-token-12345",
+		TargetUserID:    testUserID,
+		Sources:         []SourceConfig{{Type: "github", Repos: []string{"golang/go"}, Token: "ghp_secret123"}},
+		Translation:     &TranslationConfig{APIKey: "translate-key"},
+	}
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, LogConfig{Format: "json"})
+	require.NoError(t, err)
+
+	logger.Info("loaded config", "config", cfg)
+
+	out := buf.String()
+	for _, secret := range []string{"secret-token-12345", "ghp_secret123", "translate-key"} {
+		assert.NotContains(t, out, secret)
+	}
+	config := logLines(t, &buf)[0]["config"].(map[string]any)
+	assert.Equal(t, "se***45", config["line_access_token"])
+	assert.Equal(t, "tr***ey", config["translation_api_key"])
+	assert.Equal(t, testUserID, config["target_user_id"])
+}
+
+func TestLoggerFrom(t *testing.T) {
+	assert.Same(t, slog.Default(), loggerFrom(context.Background()))
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	assert.Same(t, logger, loggerFrom(withLogger(context.Background(), logger)))
+}
+
+func TestLogSourceErrors(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, LogConfig{Format: "json"})
+	require.NoError(t, err)
+	ctx := withLogger(context.Background(), logger)
+
+	logSourceErrors(ctx, errors.Join(
+		&SourceError{Source: "Lobsters", Err: errors.New("unexpected status code: 500")},
+		&SourceError{Source: "r/golang", Err: errors.New("timeout")},
+	))
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "Lobsters", lines[0]["source"])
+	assert.Equal(t, "unexpected status code: 500", lines[0]["error"])
+	assert.Equal(t, "r/golang", lines[1]["source"])
+}
+
+func TestDeliverDigest_Logging(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, LogConfig{Format: "json", Level: "debug"})
+	require.NoError(t, err)
+	ctx := withLogger(context.Background(), logger.With("run_id", "abc123"))
+
+	items := make([]Item, 7)
+	for i := range items {
+		items[i] = Item{Title: "Story", Link: "https://example.com/story"}
+	}
+	cfg := &Config{DryRun: true, TargetUserID: testUserID}
+
+	_, err = deliverDigest(ctx, cfg, &Digest{Items: items}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		assert.Equal(t, "abc123", line["run_id"])
+		assert.Equal(t, testUserID, line["target"])
+	}
+	assert.Equal(t, "1-5", lines[0]["batch"])
+	assert.Equal(t, "6-7", lines[1]["batch"])
+	assert.Equal(t, "delivered digest", lines[2]["msg"])
+
+	t.Run("failed pushes name their target and batch", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, LogConfig{Format: "json"})
+		require.NoError(t, err)
+		cfg := &Config{LineAPIURL: server.URL, LineAccessToken: "token", TargetUserID: testUserID}
+
+		_, err = deliverDigest(withLogger(context.Background(), logger), cfg, &Digest{Items: items}, &bytes.Buffer{})
+		require.Error(t, err)
+
+		lines := logLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "ERROR", lines[0]["level"])
+		assert.Equal(t, testUserID, lines[0]["target"])
+		assert.Equal(t, "1-5", lines[0]["batch"])
+		assert.Equal(t, "LINE API returned status 500: ", lines[0]["error"])
+	})
+}
+
+func TestStartRun(t *testing.T) {
+	var stderr bytes.Buffer
+	inv := &invocation{stderr: &stderr}
+	beginRun(inv, "fetch")
+	defaultLogger := slog.Default()
+
+	ctx, err := startRun(inv, &Config{Log: LogConfig{Format: "json", Level: "debug"}})
+	require.NoError(t, err)
+	loggerFrom(ctx).Info("fetched items")
+
+	assert.Same(t, defaultLogger, slog.Default(), "the default logger is left alone")
+	lines := logLines(t, &stderr)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "loaded config", lines[0]["msg"])
+	for _, line := range lines {
+		assert.Equal(t, inv.runID, line["run_id"])
+		assert.Equal(t, "fetch", line["command"])
+	}
+
+	_, err = startRun(inv, &Config{Log: LogConfig{Level: "verbose"}})
+	assert.EqualError(t, err, `LOG_LEVEL "verbose" must be debug, info, warn or error`)
+}
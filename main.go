@@ -1,34 +1,27 @@
 package main
 
 import (
-	"log"
-	"net/http"
-	"time"
+	"flag"
+	"os"
+
+	// Embedded zone data, so TIMEZONE works on hosts without a zoneinfo database
+	_ "time/tzdata"
 )
 
 func main() {
-	// Load configuration from environment variables
-	config, err := LoadConfig()
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
-	}
-
-	// Fetch news
-	news, err := getNews(config.RSSURL)
-	if err != nil {
-		log.Fatalf("Failed to get news: %v", err)
+	configPath := flag.String("config", "", "path to the YAML config file (default $IMAKOKO_CONFIG)")
+	flag.Usage = func() {
+		printUsage(flag.CommandLine.Output())
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	// Format messages
-	messages := FormatHackerNews(news)
-
-	// Send LINE messages
-	lineHTTPClient := &http.Client{Timeout: 30 * time.Second}
-	client := NewLineClient(lineHTTPClient, config.LineAPIURL, config.LineAccessToken, config.TargetUserID)
-	err = sendBatchLineMessage(client, messages)
-	if err != nil {
-		log.Fatalf("Failed to send LINE message: %v", err)
+	// Without a command, keep the original behavior of sending the digest
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"run"}
 	}
 
-	log.Println("Successfully sent messages")
+	inv := &invocation{configPath: *configPath, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(runCommand(inv, args))
 }
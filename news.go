@@ -1,10 +1,17 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,48 +21,283 @@ var rssHTTPClient = &http.Client{
 	Timeout: 30 * time.Second,
 }
 
+func init() {
+	RegisterSource("rss", newRSSSource)
+}
+
 type RSS struct {
 	Channel Channel `xml:"channel"`
 }
 
 type Channel struct {
-	Items []Item `xml:"item"`
+	Items []FeedItem `xml:"item"`
+}
+
+// FeedItem is an item of an RSS 2.0 or 1.0 feed
+type FeedItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Comments    string `xml:"comments"`
+	Description string `xml:"description"`
+	// PubDate is the RSS 2.0 publish time and Date the Dublin Core one RSS 1.0 feeds use
+	PubDate string `xml:"pubDate"`
+	Date    string `xml:"http://purl.org/dc/elements/1.1/ date"`
 }
 
 type Item struct {
-	Title string `xml:"title"`
-	Link  string `xml:"link"`
+	Title    string `json:"title"`
+	Link     string `json:"link"`
+	Comments string `json:"comments,omitempty"`
+
+	// Source is the name of the Source the item was collected from
+	Source string `json:"source"`
+	// Score and NumComments are the points and comment count the source reports, 0 when
+	// it reports none; hnrss feeds carry them in the item description
+	Score       int       `json:"score,omitempty"`
+	NumComments int       `json:"num_comments,omitempty"`
+	Published   time.Time `json:"published,omitzero"`
+
+	// OriginalTitle is the English title when Title was translated and the original is shown too
+	OriginalTitle string `json:"original_title,omitempty"`
+	// Lang is the detected language of the title as an ISO 639-1 code, empty when unknown
+	Lang string `json:"lang,omitempty"`
+	// Paywalled marks links to sites behind a hard paywall and LinkBroken links that
+	// failed the link check, when broken links are annotated rather than dropped
+	Paywalled  bool `json:"paywalled,omitempty"`
+	LinkBroken bool `json:"link_broken,omitempty"`
+	// ReadingMinutes estimates the time to read the linked article, 0 when it is unknown
+	ReadingMinutes int `json:"reading_minutes,omitempty"`
+	// Summary is a short extract of the linked article, when summaries are enabled
+	Summary string `json:"summary,omitempty"`
+	// OpenGraph is the preview metadata of the linked page, when enrichment is enabled
+	OpenGraph OpenGraph `json:"open_graph,omitzero"`
+	// AlsoSources names the other sources that covered the same story under another title
+	AlsoSources []string `json:"also_sources,omitempty"`
+}
+
+// rssSource implements Source for an RSS feed
+type rssSource struct {
+	name   string
+	rssURL string
+}
+
+func newRSSSource(cfg SourceConfig) (Source, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("rss source requires a URL")
+	}
+	name := cfg.Name
+	if name == "" {
+		u, err := url.Parse(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rss URL: %w", err)
+		}
+		name = u.Host
+	}
+	return &rssSource{name: name, rssURL: cfg.URL}, nil
+}
+
+func (s *rssSource) Name() string {
+	return s.name
+}
+
+func (s *rssSource) Fetch(ctx context.Context) ([]Item, error) {
+	return getNews(ctx, s.rssURL)
+}
+
+// fetchHNRSS fetches the feed at rssURL. When the URL points at a website instead,
+// the feed advertised by the page is followed.
+func fetchHNRSS(ctx context.Context, rssURL string) ([]byte, error) {
+	body, pageURL, err := fetchFeedDocument(ctx, rssURL)
+	if err != nil {
+		return nil, err
+	}
+	if !isHTMLDocument(body) {
+		return body, nil
+	}
+
+	candidates, err := discoverFeeds(body, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("%s is an HTML page that advertises no RSS or Atom feed", rssURL)
+	case 1:
+	default:
+		return nil, fmt.Errorf("%s advertises several feeds, use one of: %s", rssURL, strings.Join(candidates, ", "))
+	}
+
+	body, _, err = fetchFeedDocument(ctx, candidates[0])
+	if err != nil {
+		return nil, fmt.Errorf("feed %s advertised by %s: %w", candidates[0], rssURL, err)
+	}
+	if isHTMLDocument(body) {
+		return nil, fmt.Errorf("feed %s advertised by %s is an HTML page", candidates[0], rssURL)
+	}
+	return body, nil
 }
 
-func fetchHNRSS(rssURL string) ([]byte, error) {
-	resp, err := rssHTTPClient.Get(rssURL)
+// fetchFeedDocument returns the response body along with the URL it was served from after redirects
+func fetchFeedDocument(ctx context.Context, rssURL string) ([]byte, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rssURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch news: %w", err)
+		return nil, nil, fmt.Errorf("failed to make NewRequest: %w", err)
+	}
+
+	resp, err := rssHTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch news: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
 	}
-	return body, nil
+	return body, resp.Request.URL, nil
+}
+
+// Atom is the subset of an Atom feed we use; autodiscovered feeds are often Atom
+type Atom struct {
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	Title     string     `xml:"title"`
+	Links     []AtomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+}
+
+type AtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// RDF is an RSS 1.0 feed, whose items are siblings of the channel rather than inside it
+type RDF struct {
+	Items []FeedItem `xml:"item"`
 }
 
 func parseNews(data []byte) ([]Item, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("error parsing XML: %w", err)
+	}
+	switch root.XMLName.Local {
+	case "feed":
+		return parseAtom(data)
+	case "RDF":
+		var rdf RDF
+		if err := xml.Unmarshal(data, &rdf); err != nil {
+			return nil, fmt.Errorf("error parsing XML: %w", err)
+		}
+		return feedItems(rdf.Items), nil
+	}
+
 	var rss RSS
 	if err := xml.Unmarshal(data, &rss); err != nil {
 		return nil, fmt.Errorf("error parsing XML: %w", err)
 	}
 
-	return rss.Channel.Items, nil
+	return feedItems(rss.Channel.Items), nil
+}
+
+// feedItems converts the items of an RSS feed, reading their publish time and, for hnrss
+// feeds, the points and comment count from the description
+func feedItems(feed []FeedItem) []Item {
+	items := make([]Item, len(feed))
+	for i, f := range feed {
+		items[i] = Item{
+			Title:     f.Title,
+			Link:      f.Link,
+			Comments:  f.Comments,
+			Published: parseFeedTime(cmp.Or(f.PubDate, f.Date)),
+		}
+		items[i].Score, items[i].NumComments = hnrssCounts(f.Description)
+	}
+	return items
+}
+
+func parseAtom(data []byte) ([]Item, error) {
+	var atom Atom
+	if err := xml.Unmarshal(data, &atom); err != nil {
+		return nil, fmt.Errorf("error parsing XML: %w", err)
+	}
+
+	items := make([]Item, len(atom.Entries))
+	for i, entry := range atom.Entries {
+		items[i].Title = entry.Title
+		items[i].Published = parseFeedTime(cmp.Or(entry.Published, entry.Updated))
+		items[i].Score, items[i].NumComments = hnrssCounts(cmp.Or(entry.Content, entry.Summary))
+		// The entry's page is the alternate link, which is also the default rel
+		for _, link := range entry.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				items[i].Link = link.Href
+				break
+			}
+		}
+	}
+	return items, nil
+}
+
+// feedTimeLayouts are the date formats found in the wild: RFC 822 with a numeric or named
+// zone, with or without the weekday, for RSS 2.0, and RFC 3339 for Atom and Dublin Core,
+// whose W3C-DTF dates may leave out the seconds or the time
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	time.DateOnly,
+}
+
+// parseFeedTime parses a feed's publish time, returning the zero time when it is missing
+// or in an unknown format
+func parseFeedTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+var (
+	hnrssPointsPattern   = regexp.MustCompile(`Points: (\d+)`)
+	hnrssCommentsPattern = regexp.MustCompile(`# Comments: (\d+)`)
+)
+
+// hnrssCounts reads the "Points: N" and "# Comments: N" lines hnrss adds to its item
+// descriptions; other feeds have neither and count 0
+func hnrssCounts(description string) (points, comments int) {
+	if m := hnrssPointsPattern.FindStringSubmatch(description); m != nil {
+		points, _ = strconv.Atoi(m[1])
+	}
+	if m := hnrssCommentsPattern.FindStringSubmatch(description); m != nil {
+		comments, _ = strconv.Atoi(m[1])
+	}
+	return points, comments
 }
 
-func getNews(rssURL string) ([]Item, error) {
-	data, err := fetchHNRSS(rssURL)
+func getNews(ctx context.Context, rssURL string) ([]Item, error) {
+	data, err := fetchHNRSS(ctx, rssURL)
 	if err != nil {
 		return nil, err
 	}
@@ -1,13 +1,57 @@
 package main
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const testURL = "https://hnrss.org/frontpage"
 
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Hacker News: Front Page</title>
+    <item>
+      <title>First Story</title>
+      <link>https://example.com/first</link>
+    </item>
+    <item>
+      <title>Second Story</title>
+      <link>https://example.com/second</link>
+    </item>
+  </channel>
+</rss>`
+
+const testRDF = `<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF xmlns="http://purl.org/rss/1.0/" xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <channel rdf:about="https://www.jpcert.or.jp/">
+    <title>JPCERT/CC</title>
+    <link>https://www.jpcert.or.jp/</link>
+    <items>
+      <rdf:Seq>
+        <rdf:li rdf:resource="https://www.jpcert.or.jp/at/2026/at260021.html"/>
+        <rdf:li rdf:resource="https://www.jpcert.or.jp/eyes/2026/10.html"/>
+      </rdf:Seq>
+    </items>
+  </channel>
+  <item rdf:about="https://www.jpcert.or.jp/at/2026/at260021.html">
+    <title>Ivanti製品の脆弱性に関する注意喚起</title>
+    <link>https://www.jpcert.or.jp/at/2026/at260021.html</link>
+  </item>
+  <item rdf:about="https://www.jpcert.or.jp/eyes/2026/10.html">
+    <title>JPCERT/CC Eyes 10月号</title>
+    <link>https://www.jpcert.or.jp/eyes/2026/10.html</link>
+  </item>
+</rdf:RDF>`
+
 func TestGetHotNews(t *testing.T) {
-	items, err := getNews(testURL)
+	items, err := getNews(context.Background(), testURL)
 	if err != nil {
 		t.Fatalf("getHotNews() error = %v", err)
 	}
@@ -23,3 +67,130 @@ func TestGetHotNews(t *testing.T) {
 		t.Error("First item has empty link")
 	}
 }
+
+func TestRSSSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(testRSS))
+	}))
+	defer server.Close()
+
+	t.Run("fetches and parses items", func(t *testing.T) {
+		source, err := newRSSSource(SourceConfig{Type: "rss", Name: "HN", URL: server.URL})
+		require.NoError(t, err)
+
+		items, err := source.Fetch(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, "HN", source.Name())
+		require.Len(t, items, 2)
+		assert.Equal(t, "First Story", items[0].Title)
+		assert.Equal(t, "https://example.com/second", items[1].Link)
+	})
+
+	t.Run("parses RSS 1.0 feeds", func(t *testing.T) {
+		rdfServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/rdf+xml")
+			w.Write([]byte(testRDF))
+		}))
+		defer rdfServer.Close()
+		source, err := newRSSSource(SourceConfig{Type: "rss", Name: "JPCERT/CC", URL: rdfServer.URL})
+		require.NoError(t, err)
+
+		items, err := source.Fetch(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, []Item{
+			{Title: "Ivanti製品の脆弱性に関する注意喚起", Link: "https://www.jpcert.or.jp/at/2026/at260021.html"},
+			{Title: "JPCERT/CC Eyes 10月号", Link: "https://www.jpcert.or.jp/eyes/2026/10.html"},
+		}, items)
+	})
+
+	t.Run("defaults name to feed host", func(t *testing.T) {
+		source, err := newRSSSource(SourceConfig{Type: "rss", URL: "https://hnrss.org/frontpage"})
+		require.NoError(t, err)
+		assert.Equal(t, "hnrss.org", source.Name())
+	})
+
+	t.Run("requires a URL", func(t *testing.T) {
+		_, err := newRSSSource(SourceConfig{Type: "rss"})
+		assert.EqualError(t, err, "rss source requires a URL")
+	})
+}
+
+func TestFetchHNRSS_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := fetchHNRSS(context.Background(), server.URL)
+	assert.EqualError(t, err, "unexpected status code: 404")
+}
+
+func TestParseNews_Metadata(t *testing.T) {
+	t.Run("hnrss points, comments and publish time", func(t *testing.T) {
+		items, err := parseNews([]byte(`<rss version="2.0"><channel>
+  <item>
+    <title>Go 1.27 is released</title>
+    <link>https://go.dev/blog/go1.27</link>
+    <pubDate>Fri, 16 Oct 2026 09:30:00 +0000</pubDate>
+    <description><![CDATA[<p>Article URL: <a href="https://go.dev/blog/go1.27">https://go.dev/blog/go1.27</a></p>
+<p>Comments URL: <a href="https://news.ycombinator.com/item?id=1">https://news.ycombinator.com/item?id=1</a></p>
+<p>Points: 412</p>
+<p># Comments: 187</p>]]></description>
+  </item>
+  <item>
+    <title>Undated story</title>
+    <link>https://example.com/undated</link>
+    <pubDate>sometime last week</pubDate>
+  </item>
+</channel></rss>`))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, 412, items[0].Score)
+		assert.Equal(t, 187, items[0].NumComments)
+		assert.Equal(t, time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC), items[0].Published)
+		assert.Zero(t, items[1].Score)
+		assert.True(t, items[1].Published.IsZero())
+	})
+
+	t.Run("RSS 1.0 dc:date", func(t *testing.T) {
+		items, err := parseNews([]byte(`<rdf:RDF xmlns="http://purl.org/rss/1.0/" xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <item rdf:about="https://www.jpcert.or.jp/at/2026/at260021.html">
+    <title>Ivanti製品の脆弱性に関する注意喚起</title>
+    <link>https://www.jpcert.or.jp/at/2026/at260021.html</link>
+    <dc:date>2026-10-16T18:00+09:00</dc:date>
+  </item>
+  <item rdf:about="https://www.jpcert.or.jp/eyes/2026/10.html">
+    <title>JPCERT/CC Eyes 10月号</title>
+    <link>https://www.jpcert.or.jp/eyes/2026/10.html</link>
+    <dc:date>2026-10-15T10:00:00+09:00</dc:date>
+  </item>
+</rdf:RDF>`))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC), items[0].Published)
+		assert.Equal(t, time.Date(2026, 10, 15, 1, 0, 0, 0, time.UTC), items[1].Published)
+	})
+
+	t.Run("atom published, falling back to updated", func(t *testing.T) {
+		items, err := parseNews([]byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <title>Published entry</title>
+    <link href="https://blog.example.com/1"/>
+    <published>2026-10-14T08:00:00Z</published>
+    <updated>2026-10-16T08:00:00Z</updated>
+  </entry>
+  <entry>
+    <title>Updated entry</title>
+    <link href="https://blog.example.com/2"/>
+    <updated>2026-10-16T08:00:00Z</updated>
+  </entry>
+</feed>`))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC), items[0].Published)
+		assert.Equal(t, time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC), items[1].Published)
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Enrichment defaults, used for settings left at zero
+const (
+	defaultEnrichTimeout     = 10 * time.Second
+	defaultEnrichMaxBytes    = 2 * 1024 * 1024
+	defaultEnrichConcurrency = 8
+	defaultEnrichPerDomain   = 2
+	defaultEnrichCacheTTL    = 24 * time.Hour
+)
+
+// OpenGraph is the link preview metadata a page declares in its head
+type OpenGraph struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Image is an absolute URL
+	Image    string `json:"image,omitempty"`
+	SiteName string `json:"site_name,omitempty"`
+}
+
+// EnrichConfig enables fetching the Open Graph metadata and reading time of the linked
+// pages. Zero values take the defaults.
+type EnrichConfig struct {
+	// Timeout bounds fetching a single page
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxBytes is how much of a page is read; longer articles are estimated from this part
+	MaxBytes int64 `yaml:"max_bytes"`
+	// Concurrency is how many pages are fetched at once, and PerDomain how many
+	// of those may be on the same site
+	Concurrency int `yaml:"concurrency"`
+	PerDomain   int `yaml:"per_domain"`
+	// CacheDir keeps what was fetched for CacheTTL; empty uses the user cache directory
+	CacheDir string        `yaml:"cache_dir"`
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// NoCache fetches every page on every run
+	NoCache bool `yaml:"no_cache"`
+}
+
+func (c EnrichConfig) validate() error {
+	if c.Timeout < 0 || c.MaxBytes < 0 || c.Concurrency < 0 || c.PerDomain < 0 || c.CacheTTL < 0 {
+		return errors.New("enrich limits must not be negative")
+	}
+	return nil
+}
+
+// withDefaults fills in the settings left at zero
+func (c EnrichConfig) withDefaults() EnrichConfig {
+	if c.Timeout == 0 {
+		c.Timeout = defaultEnrichTimeout
+	}
+	if c.MaxBytes == 0 {
+		c.MaxBytes = defaultEnrichMaxBytes
+	}
+	if c.Concurrency == 0 {
+		c.Concurrency = defaultEnrichConcurrency
+	}
+	if c.PerDomain == 0 {
+		c.PerDomain = defaultEnrichPerDomain
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = defaultEnrichCacheTTL
+	}
+	if c.CacheDir == "" && !c.NoCache {
+		if dir, err := os.UserCacheDir(); err == nil {
+			c.CacheDir = filepath.Join(dir, "imakoko", "pages")
+		}
+	}
+	return c
+}
+
+// parseOpenGraph reads the og: properties of the page at link, falling back to the
+// standard description meta tag. A relative image is resolved against link.
+func parseOpenGraph(doc *goquery.Document, link string) OpenGraph {
+	meta := func(selectors ...string) string {
+		for _, selector := range selectors {
+			if content, ok := doc.Find(selector).First().Attr("content"); ok {
+				if content = strings.TrimSpace(content); content != "" {
+					return content
+				}
+			}
+		}
+		return ""
+	}
+
+	og := OpenGraph{
+		Title:       meta(`meta[property="og:title"]`),
+		Description: meta(`meta[property="og:description"]`, `meta[name="description"]`),
+		SiteName:    meta(`meta[property="og:site_name"]`),
+	}
+	if image := meta(`meta[property="og:image:secure_url"]`, `meta[property="og:image"]`, `meta[property="og:image:url"]`); image != "" {
+		if base, err := url.Parse(link); err == nil {
+			if u, err := base.Parse(image); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+				og.Image = u.String()
+			}
+		}
+	}
+	return og
+}
+
+// domainLimiter bounds how many requests run against the same domain at once
+type domainLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newDomainLimiter(limit int) *domainLimiter {
+	return &domainLimiter{limit: max(limit, 1), slots: make(map[string]chan struct{})}
+}
+
+// acquire blocks until a slot for domain is free and returns the function releasing it
+func (l *domainLimiter) acquire(domain string) func() {
+	l.mu.Lock()
+	slot, ok := l.slots[domain]
+	if !ok {
+		slot = make(chan struct{}, l.limit)
+		l.slots[domain] = slot
+	}
+	l.mu.Unlock()
+
+	slot <- struct{}{}
+	return func() { <-slot }
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ogPage = `<html><head>
+<meta property="og:title" content=" Go 1.27 is released ">
+<meta property="og:site_name" content="The Go Blog">
+<meta name="description" content="Generic methods arrive in Go 1.27.">
+<meta property="og:image" content="/images/go1.27.png">
+</head><body><article><p>Generic methods let a method declare its own type parameters.</p></article></body></html>`
+
+func TestParseOpenGraph(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(ogPage))
+	require.NoError(t, err)
+
+	assert.Equal(t, OpenGraph{
+		Title:       "Go 1.27 is released",
+		Description: "Generic methods arrive in Go 1.27.",
+		Image:       "https://go.dev/images/go1.27.png",
+		SiteName:    "The Go Blog",
+	}, parseOpenGraph(doc, "https://go.dev/blog/go1.27"))
+
+	t.Run("og:description wins and images must be http(s)", func(t *testing.T) {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<head>
+			<meta name="description" content="Plain description">
+			<meta property="og:description" content="Open Graph description">
+			<meta property="og:image" content="javascript:alert(1)">
+		</head>`))
+		require.NoError(t, err)
+
+		assert.Equal(t, OpenGraph{Description: "Open Graph description"}, parseOpenGraph(doc, "https://example.com/"))
+	})
+}
+
+func TestFetchArticles_Enrich(t *testing.T) {
+	var requests, inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, ogPage)
+	}))
+	defer server.Close()
+
+	newItems := func() []Item {
+		items := []Item{{Title: "Missing", Link: server.URL + "/missing"}, {Title: "Ask HN"}}
+		for i := range 4 {
+			items = append(items, Item{Title: fmt.Sprint("Post ", i), Link: fmt.Sprintf("%s/posts/%d", server.URL, i)})
+		}
+		return items
+	}
+	cfg := EnrichConfig{PerDomain: 2, CacheDir: t.TempDir()}
+
+	items := newItems()
+	fetchArticles(context.Background(), items, &cfg, nil)
+
+	assert.Zero(t, items[0].OpenGraph)
+	assert.Zero(t, items[1].OpenGraph)
+	for _, item := range items[2:] {
+		assert.Equal(t, "Go 1.27 is released", item.OpenGraph.Title)
+		assert.Equal(t, server.URL+"/images/go1.27.png", item.OpenGraph.Image)
+		assert.Equal(t, 1, item.ReadingMinutes)
+	}
+	assert.Equal(t, int32(5), requests.Load())
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2), "requests to one domain must respect per_domain")
+
+	t.Run("cached pages aren't fetched again", func(t *testing.T) {
+		requests.Store(0)
+		items := newItems()
+		fetchArticles(context.Background(), items, &cfg, nil)
+
+		assert.Equal(t, "The Go Blog", items[5].OpenGraph.SiteName)
+		assert.Equal(t, 1, items[5].ReadingMinutes)
+		// Only the page that failed is retried
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("no_cache fetches every page", func(t *testing.T) {
+		requests.Store(0)
+		fetchArticles(context.Background(), newItems(), &EnrichConfig{NoCache: true}, nil)
+
+		assert.Equal(t, int32(5), requests.Load())
+	})
+}
+
+func TestDomainLimiter(t *testing.T) {
+	limiter := newDomainLimiter(1)
+	release := limiter.acquire("example.com")
+
+	var wg sync.WaitGroup
+	acquired := make(chan string, 2)
+	for _, domain := range []string{"example.com", "go.dev"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer limiter.acquire(domain)()
+			acquired <- domain
+		}()
+	}
+
+	assert.Equal(t, "go.dev", <-acquired, "other domains aren't blocked")
+	select {
+	case domain := <-acquired:
+		t.Fatalf("%s acquired a slot that was taken", domain)
+	case <-time.After(20 * time.Millisecond):
+	}
+	release()
+	assert.Equal(t, "example.com", <-acquired)
+	wg.Wait()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// OPML is the subset of an OPML 2.0 subscription list we read and write
+type OPML struct {
+	XMLName xml.Name  `xml:"opml"`
+	Version string    `xml:"version,attr"`
+	Head    OPMLHead  `xml:"head"`
+	Body    []Outline `xml:"body>outline"`
+}
+
+type OPMLHead struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+// Outline is either a feed (it has an XMLURL) or a folder of outlines
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Category string    `xml:"category,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// importOPML reads the feeds of an OPML document as rss sources.
+// The folders a feed sits in and its category attribute become its labels.
+func importOPML(r io.Reader) ([]SourceConfig, error) {
+	var doc OPML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error parsing OPML: %w", err)
+	}
+
+	var sources []SourceConfig
+	var walk func(outlines []Outline, folders []string)
+	walk = func(outlines []Outline, folders []string) {
+		for _, outline := range outlines {
+			name := outline.Title
+			if name == "" {
+				name = outline.Text
+			}
+			if outline.XMLURL == "" {
+				walk(outline.Outlines, append(folders[:len(folders):len(folders)], name))
+				continue
+			}
+			sources = append(sources, SourceConfig{
+				Type:   "rss",
+				Name:   name,
+				URL:    outline.XMLURL,
+				Labels: outlineLabels(folders, outline.Category),
+			})
+		}
+	}
+	walk(doc.Body, nil)
+	return sources, nil
+}
+
+// outlineLabels merges folder names with the entries of an OPML category attribute,
+// a comma-separated list of slash-delimited paths such as "/Tech/Go,Security"
+func outlineLabels(folders []string, category string) []string {
+	var labels []string
+	seen := make(map[string]bool)
+	add := func(label string) {
+		label = strings.TrimSpace(label)
+		if label != "" && !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+
+	for _, folder := range folders {
+		add(folder)
+	}
+	for _, path := range strings.Split(category, ",") {
+		for _, part := range strings.Split(path, "/") {
+			add(part)
+		}
+	}
+	return labels
+}
+
+// exportOPML writes the rss sources as an OPML document. Feeds are grouped into a
+// folder named after their first label and keep all labels in the category attribute.
+func exportOPML(w io.Writer, sources []SourceConfig, now time.Time) error {
+	doc := OPML{
+		Version: "2.0",
+		Head: OPMLHead{
+			Title:       "imakoko feeds",
+			DateCreated: now.UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	folders := make(map[string]int)
+	for _, source := range sources {
+		if source.Type != "rss" {
+			continue
+		}
+		name := source.Name
+		if name == "" {
+			name = source.URL
+		}
+		feed := Outline{
+			Text:     name,
+			Title:    name,
+			Type:     "rss",
+			XMLURL:   source.URL,
+			Category: strings.Join(source.Labels, ","),
+		}
+
+		if len(source.Labels) == 0 {
+			doc.Body = append(doc.Body, feed)
+			continue
+		}
+		folder := source.Labels[0]
+		i, ok := folders[folder]
+		if !ok {
+			i = len(doc.Body)
+			folders[folder] = i
+			doc.Body = append(doc.Body, Outline{Text: folder, Title: folder})
+		}
+		doc.Body[i].Outlines = append(doc.Body[i].Outlines, feed)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write OPML: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write OPML: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write OPML: %w", err)
+	}
+	return nil
+}
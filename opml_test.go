@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportOPML(t *testing.T) {
+	t.Run("keeps folders and categories as labels", func(t *testing.T) {
+		file, err := os.Open("testdata/feeds.opml")
+		require.NoError(t, err)
+		defer file.Close()
+
+		sources, err := importOPML(file)
+		require.NoError(t, err)
+		assert.Equal(t, []SourceConfig{
+			{Type: "rss", Name: "Hacker News", URL: "https://hnrss.org/frontpage"},
+			{Type: "rss", Name: "The Go Blog", URL: "https://go.dev/blog/feed.atom", Labels: []string{"Tech", "Go"}},
+			{Type: "rss", Name: "Lobsters", URL: "https://lobste.rs/rss", Labels: []string{"Tech", "Aggregators", "Daily"}},
+			{Type: "rss", Name: "JPCERT/CC", URL: "https://www.jpcert.or.jp/rss/jpcert.rdf", Labels: []string{"セキュリティ"}},
+		}, sources)
+	})
+
+	t.Run("returns error on malformed OPML", func(t *testing.T) {
+		_, err := importOPML(strings.NewReader("<opml><body>"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error parsing OPML")
+	})
+}
+
+func TestExportOPML(t *testing.T) {
+	now := time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC)
+
+	t.Run("groups feeds by first label and skips non-rss sources", func(t *testing.T) {
+		sources := []SourceConfig{
+			{Type: "rss", URL: "https://hnrss.org/frontpage"},
+			{Type: "rss", Name: "The Go Blog", URL: "https://go.dev/blog/feed.atom", Labels: []string{"Tech", "Go"}},
+			{Type: "reddit", Subreddit: "golang"},
+			{Type: "rss", Name: "Lobsters", URL: "https://lobste.rs/rss", Labels: []string{"Tech"}},
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, exportOPML(&buf, sources, now))
+
+		expected := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head>
+    <title>imakoko feeds</title>
+    <dateCreated>Fri, 16 Oct 2026 07:00:00 +0000</dateCreated>
+  </head>
+  <body>
+    <outline text="https://hnrss.org/frontpage" title="https://hnrss.org/frontpage" type="rss" xmlUrl="https://hnrss.org/frontpage"></outline>
+    <outline text="Tech" title="Tech">
+      <outline text="The Go Blog" title="The Go Blog" type="rss" xmlUrl="https://go.dev/blog/feed.atom" category="Tech,Go"></outline>
+      <outline text="Lobsters" title="Lobsters" type="rss" xmlUrl="https://lobste.rs/rss" category="Tech"></outline>
+    </outline>
+  </body>
+</opml>
+`
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("round trips through import", func(t *testing.T) {
+		file, err := os.Open("testdata/feeds.opml")
+		require.NoError(t, err)
+		defer file.Close()
+
+		sources, err := importOPML(file)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, exportOPML(&buf, sources, now))
+
+		reimported, err := importOPML(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, sources, reimported)
+	})
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// fetchItems collects the items like collectItems and keeps those in the configured
+// languages, marking those from paywalled sites. When configured, it then checks their
+// links, keeps the top ones by score, and fetches the preview metadata and summaries of
+// the articles they link.
+func fetchItems(ctx context.Context, cfg *Config) ([]Item, error) {
+	items, err := collectItems(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	tagLanguages(items)
+	items = filterLanguages(ctx, items, cfg.Languages)
+	markPaywalled(items, cfg.PaywallDomains)
+	// Dead links are dropped before ranking so they don't take a place in the top
+	if cfg.LinkCheck != nil {
+		items = checkLinks(ctx, items, *cfg.LinkCheck)
+	}
+	if cfg.Ranking != nil {
+		r, err := newRanker(*cfg.Ranking)
+		if err != nil {
+			return nil, err
+		}
+		items = r.top(ctx, items)
+	}
+	// Only the items that made the cut are worth fetching, each page once for both steps
+	if cfg.Enrich != nil || cfg.Summary != nil {
+		fetchArticles(ctx, items, cfg.Enrich, cfg.Summary)
+	}
+	return items, nil
+}
+
+// collectItems builds the configured sources, collects their items and removes articles
+// linked by several of them, collapsing stories covered under similar titles.
+// Failing sources are logged; it only fails when nothing could be collected.
+func collectItems(ctx context.Context, cfg *Config) ([]Item, error) {
+	sources, err := NewSources(cfg.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up sources: %w", err)
+	}
+
+	items, err := collectNews(ctx, sources)
+	if err != nil {
+		if len(items) == 0 {
+			return nil, fmt.Errorf("failed to get news: %w", err)
+		}
+		logSourceErrors(ctx, err)
+	}
+
+	priorities := make(map[string]int, len(sources))
+	for i, source := range sources {
+		priorities[source.Name()] = max(priorities[source.Name()], cfg.Sources[i].Priority)
+	}
+	priority := func(source string) int { return priorities[source] }
+	items = dedupeItems(ctx, items, priority)
+	return collapseNearDuplicates(ctx, items, cfg.Dedupe.similarity(), priority), nil
+}
+
+// logSourceErrors logs each failed source of a collectNews error on its own line
+func logSourceErrors(ctx context.Context, err error) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var sourceErr *SourceError
+		if errors.As(err, &sourceErr) {
+			loggerFrom(ctx).Warn("source failed", "source", sourceErr.Source, "error", sourceErr.Err)
+		} else {
+			loggerFrom(ctx).Warn("source failed", "error", err)
+		}
+	}
+}
+
+// fetchDigest collects the items like fetchItems and records what the sources filtered out,
+// dated in the configured timezone
+func fetchDigest(ctx context.Context, cfg *Config) (*Digest, error) {
+	ctx, dropped := withDropCounter(ctx)
+	items, err := fetchItems(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Digest{
+		Items:   items,
+		Date:    time.Now().In(cfg.location()),
+		Dropped: dropped.list(),
+	}, nil
+}
+
+// newFormatter returns the template formatter when one is configured, or the built-in layout,
+// packing the items into sections when grouping is enabled and adding the summary header
+// and footer when they are enabled.
+func newFormatter(cfg *Config, l *Localizer) (Formatter, error) {
+	var formatter Formatter = hackerNewsFormatter{localizer: l}
+	var items itemRenderer = hackerNewsFormatter{localizer: l}
+	var tmpl *templateFormatter
+	if cfg.Format.enabled() {
+		var err error
+		if tmpl, err = newTemplateFormatter(cfg.Format, l); err != nil {
+			return nil, err
+		}
+		formatter, items = tmpl, tmpl
+	}
+
+	if cfg.Format.GroupBy != "" {
+		// The sections share the push with the header and footer messages
+		grouped, err := newGroupedFormatter(cfg.Format, items, l, maxBatchSize-cfg.Format.extraMessages())
+		if err != nil {
+			return nil, err
+		}
+		if tmpl != nil {
+			tmpl.body = grouped
+		} else {
+			formatter = grouped
+		}
+	}
+
+	if cfg.Format.SummaryHeader || cfg.Format.SummaryFooter {
+		formatter = summaryFormatter{
+			Formatter: formatter,
+			localizer: l,
+			header:    cfg.Format.SummaryHeader,
+			footer:    cfg.Format.SummaryFooter,
+		}
+	}
+
+	return formatter, nil
+}
+
+// formatMessages turns the digest into LINE message texts in the given locale
+func formatMessages(cfg *Config, locale string, d *Digest) ([]string, error) {
+	l, err := NewLocalizer(locale)
+	if err != nil {
+		return nil, err
+	}
+	formatter, err := newFormatter(cfg, l)
+	if err != nil {
+		return nil, err
+	}
+	return formatter.Format(d)
+}
+
+// deliverDigest formats the digest in the locale of every target, with the items in the
+// target's languages, and pushes it to each, returning how many messages were sent in total
+func deliverDigest(ctx context.Context, cfg *Config, d *Digest, dryRunOut io.Writer) (int, error) {
+	targets := cfg.targets()
+	translated := make(map[string]*Digest)
+	sent := 0
+	for _, target := range targets {
+		logger := loggerFrom(ctx).With("target", target.UserID)
+		localized, ok := translated[target.Locale]
+		if !ok {
+			localized = translateDigest(withLogger(ctx, logger), cfg, target.Locale, d)
+			translated[target.Locale] = localized
+		}
+		messages, err := formatMessages(cfg, target.Locale, localized.forLanguages(target.Languages))
+		if err != nil {
+			return sent, err
+		}
+		sender := newLineSender(cfg, target.UserID, len(targets) > 1, dryRunOut)
+		if err := sendBatchLineMessage(withLogger(ctx, logger), sender, messages); err != nil {
+			return sent, fmt.Errorf("failed to send LINE message to %s: %w", target.UserID, err)
+		}
+		logger.Info("delivered digest", "locale", target.Locale, "messages", len(messages))
+		sent += len(messages)
+	}
+	return sent, nil
+}
+
+// newLineSender creates the MessageSender pushing to one LINE target, or recording the
+// payloads to dryRunOut or DryRunDir in a dry run. With several targets each one's
+// payload files go to a subdirectory named after it.
+func newLineSender(cfg *Config, targetUserID string, multipleTargets bool, dryRunOut io.Writer) MessageSender {
+	if cfg.DryRun {
+		dir := cfg.DryRunDir
+		if dir != "" && multipleTargets {
+			dir = filepath.Join(dir, targetUserID)
+		}
+		return NewDryRunSender(targetUserID, dir, dryRunOut)
+	}
+	lineHTTPClient := &http.Client{Timeout: 30 * time.Second}
+	return NewLineClient(lineHTTPClient, cfg.LineAPIURL, cfg.LineAccessToken, targetUserID)
+}
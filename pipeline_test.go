@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchItems(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(testRSS))
+	}))
+	defer feed.Close()
+
+	t.Run("keeps items when some sources fail", func(t *testing.T) {
+		cfg := &Config{Sources: []SourceConfig{
+			{Type: "rss", Name: "broken", URL: feed.URL + "/broken"},
+			{Type: "rss", Name: "HN", URL: feed.URL},
+		}}
+
+		items, err := fetchItems(context.Background(), cfg)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "HN", items[0].Source)
+	})
+
+	t.Run("keeps one entry per article, from the highest priority source", func(t *testing.T) {
+		cfg := &Config{Sources: []SourceConfig{
+			{Type: "rss", Name: "HN", URL: feed.URL},
+			{Type: "rss", Name: "Mirror", URL: feed.URL + "/mirror", Priority: 1},
+		}}
+
+		items, err := fetchItems(context.Background(), cfg)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "Mirror", items[0].Source)
+		assert.Equal(t, "Mirror", items[1].Source)
+	})
+
+	t.Run("marks paywalled items without a link check", func(t *testing.T) {
+		cfg := &Config{
+			Sources:        []SourceConfig{{Type: "rss", Name: "HN", URL: feed.URL}},
+			PaywallDomains: []string{"example.com"},
+		}
+
+		items, err := fetchItems(context.Background(), cfg)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.True(t, items[0].Paywalled)
+		assert.True(t, items[1].Paywalled)
+	})
+
+	t.Run("fails when every source fails", func(t *testing.T) {
+		cfg := &Config{Sources: []SourceConfig{{Type: "rss", Name: "broken", URL: feed.URL + "/broken"}}}
+
+		items, err := fetchItems(context.Background(), cfg)
+		assert.Nil(t, items)
+		assert.EqualError(t, err, "failed to get news: source broken: unexpected status code: 500")
+	})
+
+	t.Run("fails on unusable source config", func(t *testing.T) {
+		cfg := &Config{Sources: []SourceConfig{{Type: "gopher"}}}
+
+		_, err := fetchItems(context.Background(), cfg)
+		assert.EqualError(t, err, `failed to set up sources: source 1: unknown source type "gopher"`)
+	})
+}
+
+func TestFetchDigest(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testRSS))
+	}))
+	defer feed.Close()
+
+	cfg := &Config{
+		Sources:  []SourceConfig{{Type: "rss", Name: "HN", URL: feed.URL}},
+		Timezone: "Asia/Tokyo",
+	}
+
+	digest, err := fetchDigest(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Len(t, digest.Items, 2)
+	assert.Equal(t, "Asia/Tokyo", digest.Date.Location().String())
+	assert.Empty(t, digest.Dropped)
+
+	cfg.Format = FormatConfig{SummaryHeader: true}
+	messages, err := formatMessages(cfg, "ja", digest)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Contains(t, messages[0], "のニュース: 2件 (HN 2)")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Ranking defaults, used for weights left unset and a zero gravity
+const (
+	defaultPointsWeight   = 1.0
+	defaultCommentsWeight = 1.0
+	defaultGravity        = 1.8
+)
+
+// RankingConfig weighs the signals items are ranked by. Unset weights take the defaults;
+// a weight of 0 turns its signal off.
+type RankingConfig struct {
+	// TopN keeps only the best ranked items; 0 keeps them all
+	TopN int `yaml:"top_n"`
+	// PointsWeight multiplies the item's score or points
+	PointsWeight *float64 `yaml:"points_weight"`
+	// CommentsWeight multiplies the comments per hour since publishing
+	CommentsWeight *float64 `yaml:"comments_weight"`
+	// Gravity is the exponent of the HN-style age decay: score / (age in hours + 2)^gravity
+	Gravity float64 `yaml:"gravity"`
+	// SourceWeights multiply the score of items by source name; sources not listed weigh 1
+	SourceWeights map[string]float64 `yaml:"source_weights"`
+	// Keywords add their boost to items whose title contains them; negative boosts demote
+	Keywords map[string]float64 `yaml:"keywords"`
+}
+
+func (c RankingConfig) validate() error {
+	var errs []error
+	if c.TopN < 0 {
+		errs = append(errs, fmt.Errorf("ranking.top_n %d must not be negative", c.TopN))
+	}
+	if isNegative(c.PointsWeight) || isNegative(c.CommentsWeight) || c.Gravity < 0 {
+		errs = append(errs, errors.New("ranking weights and gravity must not be negative"))
+	}
+	for source, weight := range c.SourceWeights {
+		if weight <= 0 {
+			errs = append(errs, fmt.Errorf("ranking.source_weights[%s] %g must be positive", source, weight))
+		}
+	}
+	for keyword := range c.Keywords {
+		if keyword == "" {
+			errs = append(errs, errors.New("ranking.keywords must not contain an empty keyword"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isNegative(weight *float64) bool {
+	return weight != nil && *weight < 0
+}
+
+// scoreBreakdown is how an item's ranking score came about
+type scoreBreakdown struct {
+	Item     Item
+	Points   float64
+	Velocity float64
+	// Keywords lists the matched keywords and KeywordBoost their total boost
+	Keywords     []string
+	KeywordBoost float64
+	SourceWeight float64
+	// AgeHours is negative when the item has no publish time; such items are decayed
+	// as if just published
+	AgeHours float64
+	Decay    float64
+	Score    float64
+}
+
+type keywordBoost struct {
+	keyword string
+	pattern *regexp.Regexp
+	boost   float64
+}
+
+// ranker scores items by points, comment velocity, keywords and source, decayed by age
+type ranker struct {
+	cfg RankingConfig
+	// pointsWeight and commentsWeight are the configured weights or their defaults
+	pointsWeight   float64
+	commentsWeight float64
+	keywords       []keywordBoost
+	now            func() time.Time
+}
+
+func newRanker(cfg RankingConfig) (*ranker, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Gravity == 0 {
+		cfg.Gravity = defaultGravity
+	}
+
+	r := &ranker{cfg: cfg, pointsWeight: defaultPointsWeight, commentsWeight: defaultCommentsWeight, now: time.Now}
+	if cfg.PointsWeight != nil {
+		r.pointsWeight = *cfg.PointsWeight
+	}
+	if cfg.CommentsWeight != nil {
+		r.commentsWeight = *cfg.CommentsWeight
+	}
+	for keyword, boost := range cfg.Keywords {
+		r.keywords = append(r.keywords, keywordBoost{keyword: keyword, pattern: keywordPattern(keyword), boost: boost})
+	}
+	// Map order is random; keep explanations stable
+	slices.SortFunc(r.keywords, func(a, b keywordBoost) int {
+		return cmp.Compare(a.keyword, b.keyword)
+	})
+	return r, nil
+}
+
+func (r *ranker) score(item Item, now time.Time) scoreBreakdown {
+	b := scoreBreakdown{
+		Item:         item,
+		Points:       float64(item.Score),
+		SourceWeight: 1,
+		AgeHours:     -1,
+		Decay:        1 / math.Pow(2, r.cfg.Gravity),
+	}
+	if weight, ok := r.cfg.SourceWeights[item.Source]; ok {
+		b.SourceWeight = weight
+	}
+	if !item.Published.IsZero() {
+		b.AgeHours = max(now.Sub(item.Published).Hours(), 0)
+		b.Velocity = float64(item.NumComments) / max(b.AgeHours, 1)
+		b.Decay = 1 / math.Pow(b.AgeHours+2, r.cfg.Gravity)
+	}
+	for _, k := range r.keywords {
+		if k.pattern.MatchString(item.Title) {
+			b.Keywords = append(b.Keywords, k.keyword)
+			b.KeywordBoost += k.boost
+		}
+	}
+
+	base := max(1+r.pointsWeight*b.Points+r.commentsWeight*b.Velocity+b.KeywordBoost, 0)
+	b.Score = base * b.SourceWeight * b.Decay
+	return b
+}
+
+// rank scores every item and orders them best first; equal scores keep their order
+func (r *ranker) rank(items []Item) []scoreBreakdown {
+	now := r.now()
+	ranked := make([]scoreBreakdown, len(items))
+	for i, item := range items {
+		ranked[i] = r.score(item, now)
+	}
+	slices.SortStableFunc(ranked, func(a, b scoreBreakdown) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return ranked
+}
+
+// top orders the items by rank and keeps the best TopN, recording the rest as dropped
+func (r *ranker) top(ctx context.Context, items []Item) []Item {
+	ranked := r.rank(items)
+	kept := make([]Item, 0, len(ranked))
+	for i, b := range ranked {
+		if r.cfg.TopN > 0 && i >= r.cfg.TopN {
+			recordDropped(ctx, dropRankedOut)
+			continue
+		}
+		kept = append(kept, b.Item)
+	}
+	return kept
+}
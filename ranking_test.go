@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRanker(t *testing.T, cfg RankingConfig, now time.Time) *ranker {
+	r, err := newRanker(cfg)
+	require.NoError(t, err)
+	r.now = func() time.Time { return now }
+	return r
+}
+
+// weight returns a pointer to w for the optional ranking weights
+func weight(w float64) *float64 {
+	return &w
+}
+
+func TestRanker_Score(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	r := newTestRanker(t, RankingConfig{
+		CommentsWeight: weight(0.5),
+		SourceWeights:  map[string]float64{"Lobsters": 2},
+		Keywords:       map[string]float64{"Go": 50, "crypto": -20},
+	}, now)
+
+	b := r.score(Item{
+		Title:       "Go 1.27 is released",
+		Source:      "Lobsters",
+		Score:       100,
+		NumComments: 40,
+		Published:   now.Add(-4 * time.Hour),
+	}, now)
+
+	assert.Equal(t, 100.0, b.Points)
+	assert.Equal(t, 10.0, b.Velocity)
+	assert.Equal(t, []string{"Go"}, b.Keywords)
+	assert.Equal(t, 50.0, b.KeywordBoost)
+	assert.Equal(t, 2.0, b.SourceWeight)
+	assert.Equal(t, 4.0, b.AgeHours)
+	assert.InDelta(t, 1/math.Pow(6, defaultGravity), b.Decay, 1e-9)
+	// (1 + 100 + 0.5*10 + 50) * 2 * decay
+	assert.InDelta(t, 312*b.Decay, b.Score, 1e-9)
+
+	t.Run("undated items decay as if just published", func(t *testing.T) {
+		b := r.score(Item{Title: "crypto winter", Score: 10}, now)
+		assert.Equal(t, -1.0, b.AgeHours)
+		assert.Zero(t, b.Velocity)
+		assert.InDelta(t, 1/math.Pow(2, defaultGravity), b.Decay, 1e-9)
+		// A negative boost can't push the score below zero
+		assert.Zero(t, b.Score)
+	})
+
+	t.Run("zero weights turn their signal off", func(t *testing.T) {
+		r := newTestRanker(t, RankingConfig{PointsWeight: weight(0), CommentsWeight: weight(0)}, now)
+		b := r.score(Item{Title: "busy", Score: 100, NumComments: 40, Published: now.Add(-4 * time.Hour)}, now)
+		assert.InDelta(t, b.Decay, b.Score, 1e-9)
+	})
+}
+
+func TestRanker_Top(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	items := []Item{
+		{Title: "old but popular", Score: 500, Published: now.Add(-48 * time.Hour)},
+		{Title: "fresh and busy", Score: 80, NumComments: 60, Published: now.Add(-1 * time.Hour)},
+		{Title: "fresh and quiet", Score: 5, Published: now.Add(-1 * time.Hour)},
+		{Title: "middling", Score: 150, Published: now.Add(-6 * time.Hour)},
+	}
+	r := newTestRanker(t, RankingConfig{TopN: 2}, now)
+
+	ctx, dropped := withDropCounter(context.Background())
+	top := r.top(ctx, items)
+
+	require.Len(t, top, 2)
+	assert.Equal(t, "fresh and busy", top[0].Title)
+	assert.Equal(t, "middling", top[1].Title)
+	assert.Equal(t, []DropCount{{Reason: dropRankedOut, Count: 2}}, dropped.list())
+
+	t.Run("keeps everything without top_n, equal scores in order", func(t *testing.T) {
+		r := newTestRanker(t, RankingConfig{}, now)
+		same := []Item{{Title: "a"}, {Title: "b"}, {Title: "c"}}
+		assert.Equal(t, same, r.top(context.Background(), same))
+	})
+}
+
+func TestRanker_FeedItems(t *testing.T) {
+	// Points and comments as hnrss puts them in the description
+	item := func(title, pubDate string, points, comments int) string {
+		return fmt.Sprintf(`<item><title>%s</title><link>https://example.com/%d</link><pubDate>%s</pubDate>`+
+			`<description><![CDATA[<p>Points: %d</p><p># Comments: %d</p>]]></description></item>`,
+			title, points, pubDate, points, comments)
+	}
+	items, err := parseNews([]byte(`<rss version="2.0"><channel>` +
+		item("last year's hit", "Wed, 14 Feb 2024 09:00:00 +0000", 900, 400) +
+		item("quiet this morning", "Fri, 16 Oct 2026 09:00:00 +0000", 4, 0) +
+		item("busy this morning", "Fri, 16 Oct 2026 09:00:00 +0000", 120, 90) +
+		`</channel></rss>`))
+	require.NoError(t, err)
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	ranked := newTestRanker(t, RankingConfig{}, now).rank(items)
+
+	titles := make([]string, len(ranked))
+	for i, b := range ranked {
+		titles[i] = b.Item.Title
+	}
+	assert.Equal(t, []string{"busy this morning", "quiet this morning", "last year's hit"}, titles)
+	assert.Equal(t, 120.0, ranked[0].Points)
+	assert.Equal(t, 30.0, ranked[0].Velocity)
+	assert.Equal(t, 3.0, ranked[0].AgeHours)
+	assert.Greater(t, ranked[1].Score, ranked[2].Score, "age decays even a popular item")
+}
+
+func TestRankingConfig_Validate(t *testing.T) {
+	err := RankingConfig{
+		TopN:          -1,
+		PointsWeight:  weight(-1),
+		Gravity:       -2,
+		SourceWeights: map[string]float64{"HN": 0},
+		Keywords:      map[string]float64{"": 1},
+	}.validate()
+
+	assert.EqualError(t, err, "ranking.top_n -1 must not be negative\n"+
+		"ranking weights and gravity must not be negative\n"+
+		"ranking.source_weights[HN] 0 must be positive\n"+
+		"ranking.keywords must not contain an empty keyword")
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"math"
+	"unicode"
+)
+
+// Reading speeds: English is read by the word, Japanese, which has no spaces, by the character
+const (
+	wordsPerMinute         = 230
+	japaneseCharsPerMinute = 500
+)
+
+// readingMinutes estimates how long text takes to read, rounded up to whole minutes.
+// CJK characters count at the Japanese rate and the rest is counted in words.
+func readingMinutes(text string) int {
+	var chars, words int
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r) || unicode.Is(unicode.Hangul, r):
+			chars++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	return int(math.Ceil(float64(words)/wordsPerMinute + float64(chars)/japaneseCharsPerMinute))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadingMinutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{"empty", "", 0},
+		{"a few words", "Go 1.27 is out.", 1},
+		{"English by the word", strings.Repeat("word ", 1380), 6},
+		{"Japanese by the character", strings.Repeat("東京都は新しい防災アプリの配信を始めた。", 150), 6},
+		{"mixed", strings.Repeat("word ", 230) + strings.Repeat("あ", 500), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, readingMinutes(tt.text))
+		})
+	}
+}
+
+func TestFormatHackerNews_ReadingTime(t *testing.T) {
+	item := Item{Title: "Go 1.27 released", Link: "https://go.dev/blog/go1.27", ReadingMinutes: 6}
+
+	assert.Equal(t, []string{"1. Go 1.27 released\nhttps://go.dev/blog/go1.27 ・ 約6分"}, FormatHackerNews([]Item{item}))
+
+	msg, err := hackerNewsFormatter{localizer: newTestLocalizer(t, "en")}.renderItem(item, 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "2. Go 1.27 released\nhttps://go.dev/blog/go1.27 ・ ~6 min read", msg)
+
+	item.ReadingMinutes = 0
+	assert.Equal(t, []string{"1. Go 1.27 released\nhttps://go.dev/blog/go1.27"}, FormatHackerNews([]Item{item}), "unknown reading times are left out")
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRedditURL       = "https://www.reddit.com"
+	defaultRedditUserAgent = "imakoko/1.0 (news digest)"
+)
+
+func init() {
+	RegisterSource("reddit", newRedditSource)
+}
+
+// redditListing is the subset of the subreddit listing JSON we use
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Data redditPost `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type redditPost struct {
+	Title       string  `json:"title"`
+	URL         string  `json:"url"`
+	Permalink   string  `json:"permalink"`
+	Score       int     `json:"score"`
+	NumComments int     `json:"num_comments"`
+	Stickied    bool    `json:"stickied"`
+	Over18      bool    `json:"over_18"`
+	CreatedUTC  float64 `json:"created_utc"`
+}
+
+// redditSource implements Source for a subreddit's daily top listing
+type redditSource struct {
+	httpClient  *http.Client
+	name        string
+	baseURL     string
+	subreddit   string
+	userAgent   string
+	minScore    int
+	minComments int
+}
+
+func newRedditSource(cfg SourceConfig) (Source, error) {
+	subreddit := strings.TrimPrefix(cfg.Subreddit, "r/")
+	if subreddit == "" {
+		return nil, errors.New("reddit source requires a subreddit")
+	}
+	name := cfg.Name
+	if name == "" {
+		name = "r/" + subreddit
+	}
+	baseURL := cfg.URL
+	if baseURL == "" {
+		baseURL = defaultRedditURL
+	}
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = defaultRedditUserAgent
+	}
+	return &redditSource{
+		httpClient:  rssHTTPClient,
+		name:        name,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		subreddit:   subreddit,
+		userAgent:   userAgent,
+		minScore:    cfg.MinScore,
+		minComments: cfg.MinComments,
+	}, nil
+}
+
+func (s *redditSource) Name() string {
+	return s.name
+}
+
+func (s *redditSource) Fetch(ctx context.Context) ([]Item, error) {
+	listingURL := fmt.Sprintf("%s/r/%s/top.json?t=day", s.baseURL, url.PathEscape(s.subreddit))
+	req, err := http.NewRequestWithContext(ctx, "GET", listingURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make NewRequest: %w", err)
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch subreddit: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return s.parseListing(ctx, body)
+}
+
+func (s *redditSource) parseListing(ctx context.Context, data []byte) ([]Item, error) {
+	var listing redditListing
+	if err := json.Unmarshal(data, &listing); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+
+	items := make([]Item, 0, len(listing.Data.Children))
+	for _, child := range listing.Data.Children {
+		post := child.Data
+		if post.Stickied {
+			recordDropped(ctx, dropStickied)
+			continue
+		}
+		if post.Over18 {
+			recordDropped(ctx, dropNSFW)
+			continue
+		}
+		if post.Score < s.minScore || post.NumComments < s.minComments {
+			recordDropped(ctx, dropLowScore)
+			continue
+		}
+		items = append(items, Item{
+			Title:       post.Title,
+			Link:        post.URL,
+			Comments:    s.baseURL + post.Permalink,
+			Score:       post.Score,
+			NumComments: post.NumComments,
+			Published:   time.Unix(int64(post.CreatedUTC), 0).UTC(),
+		})
+	}
+	return items, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRedditFixtureServer serves the recorded listing fixture for r/golang
+func newRedditFixtureServer(t *testing.T, wantUserAgent string) *httptest.Server {
+	fixture, err := os.ReadFile("testdata/reddit_top.json")
+	require.NoError(t, err)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/r/golang/top.json", r.URL.Path)
+		assert.Equal(t, "day", r.URL.Query().Get("t"))
+		assert.Equal(t, wantUserAgent, r.Header.Get("User-Agent"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+}
+
+func TestRedditSource_Fetch(t *testing.T) {
+	t.Run("maps posts and skips stickied and NSFW", func(t *testing.T) {
+		server := newRedditFixtureServer(t, "imakoko-test/0.1")
+		defer server.Close()
+
+		source, err := newRedditSource(SourceConfig{Type: "reddit", URL: server.URL, Subreddit: "golang", UserAgent: "imakoko-test/0.1"})
+		require.NoError(t, err)
+
+		items, err := source.Fetch(context.Background())
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+
+		assert.Equal(t, Item{
+			Title:       "Go 1.27 is released",
+			Link:        "https://go.dev/blog/go1.27",
+			Comments:    server.URL + "/r/golang/comments/1g4a9zq/go_127_is_released/",
+			Score:       912,
+			NumComments: 143,
+			Published:   time.Unix(1792051200, 0).UTC(),
+		}, items[0])
+		assert.Equal(t, "Profiling allocation hot spots with pprof", items[1].Title)
+		assert.Equal(t, "Is there a generic ordered map yet?", items[2].Title)
+	})
+
+	t.Run("applies score and comment thresholds", func(t *testing.T) {
+		server := newRedditFixtureServer(t, defaultRedditUserAgent)
+		defer server.Close()
+
+		source, err := newRedditSource(SourceConfig{Type: "reddit", URL: server.URL, Subreddit: "r/golang", MinScore: 50, MinComments: 10})
+		require.NoError(t, err)
+
+		ctx, dropped := withDropCounter(context.Background())
+		items, err := source.Fetch(ctx)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "Go 1.27 is released", items[0].Title)
+		assert.Equal(t, "Profiling allocation hot spots with pprof", items[1].Title)
+		assert.Equal(t, []DropCount{
+			{Reason: dropStickied, Count: 1},
+			{Reason: dropNSFW, Count: 1},
+			{Reason: dropLowScore, Count: 1},
+		}, dropped.list())
+	})
+
+	t.Run("returns error on non-200 status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		source, err := newRedditSource(SourceConfig{Type: "reddit", URL: server.URL, Subreddit: "golang"})
+		require.NoError(t, err)
+
+		_, err = source.Fetch(context.Background())
+		assert.EqualError(t, err, "unexpected status code: 429")
+	})
+
+	t.Run("returns error on malformed JSON", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`<html>blocked</html>`))
+		}))
+		defer server.Close()
+
+		source, err := newRedditSource(SourceConfig{Type: "reddit", URL: server.URL, Subreddit: "golang"})
+		require.NoError(t, err)
+
+		_, err = source.Fetch(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error parsing JSON")
+	})
+}
+
+func TestNewRedditSource(t *testing.T) {
+	t.Run("defaults name and base URL", func(t *testing.T) {
+		source, err := newRedditSource(SourceConfig{Type: "reddit", Subreddit: "programming"})
+		require.NoError(t, err)
+
+		reddit := source.(*redditSource)
+		assert.Equal(t, "r/programming", reddit.Name())
+		assert.Equal(t, defaultRedditURL, reddit.baseURL)
+		assert.Equal(t, defaultRedditUserAgent, reddit.userAgent)
+	})
+
+	t.Run("requires a subreddit", func(t *testing.T) {
+		_, err := newRedditSource(SourceConfig{Type: "reddit"})
+		assert.EqualError(t, err, "reddit source requires a subreddit")
+	})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+func init() {
+	RegisterSource("scrape", newScrapeSource)
+}
+
+// scrapeSource implements Source for a web page without a feed, using CSS selectors
+type scrapeSource struct {
+	httpClient    *http.Client
+	name          string
+	pageURL       string
+	itemSelector  cascadia.Selector
+	titleSelector cascadia.Selector
+	linkSelector  cascadia.Selector
+}
+
+func newScrapeSource(cfg SourceConfig) (Source, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("scrape source requires a URL")
+	}
+	pageURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL: %w", err)
+	}
+	if cfg.ItemSelector == "" {
+		return nil, errors.New("scrape source requires an item selector")
+	}
+
+	itemSelector, err := cascadia.Compile(cfg.ItemSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid item selector %q: %w", cfg.ItemSelector, err)
+	}
+	// Title and link selectors are optional and matched inside each item
+	var titleSelector, linkSelector cascadia.Selector
+	if cfg.TitleSelector != "" {
+		if titleSelector, err = cascadia.Compile(cfg.TitleSelector); err != nil {
+			return nil, fmt.Errorf("invalid title selector %q: %w", cfg.TitleSelector, err)
+		}
+	}
+	if cfg.LinkSelector != "" {
+		if linkSelector, err = cascadia.Compile(cfg.LinkSelector); err != nil {
+			return nil, fmt.Errorf("invalid link selector %q: %w", cfg.LinkSelector, err)
+		}
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = pageURL.Host
+	}
+	return &scrapeSource{
+		httpClient:    rssHTTPClient,
+		name:          name,
+		pageURL:       cfg.URL,
+		itemSelector:  itemSelector,
+		titleSelector: titleSelector,
+		linkSelector:  linkSelector,
+	}, nil
+}
+
+func (s *scrapeSource) Name() string {
+	return s.name
+}
+
+func (s *scrapeSource) Fetch(ctx context.Context) ([]Item, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make NewRequest: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML: %w", err)
+	}
+	// Links are relative to the page we ended up on after redirects
+	return s.extractItems(doc, resp.Request.URL), nil
+}
+
+func (s *scrapeSource) extractItems(doc *goquery.Document, pageURL *url.URL) []Item {
+	base := pageURL
+	if href, ok := doc.Find("base[href]").Attr("href"); ok {
+		if u, err := pageURL.Parse(href); err == nil {
+			base = u
+		}
+	}
+
+	var items []Item
+	doc.FindMatcher(s.itemSelector).Each(func(_ int, sel *goquery.Selection) {
+		title := sel
+		if s.titleSelector != nil {
+			title = sel.FindMatcher(s.titleSelector).First()
+		}
+
+		link := sel.Filter("a[href]")
+		if s.linkSelector != nil {
+			link = sel.FindMatcher(s.linkSelector).First()
+		} else if link.Length() == 0 {
+			link = sel.Find("a[href]").First()
+		}
+
+		item := Item{Title: strings.Join(strings.Fields(title.Text()), " ")}
+		if href, ok := link.Attr("href"); ok {
+			if u, err := base.Parse(strings.TrimSpace(href)); err == nil {
+				item.Link = u.String()
+			}
+		}
+		if item.Title == "" || item.Link == "" {
+			return
+		}
+		items = append(items, item)
+	})
+	return items
+}
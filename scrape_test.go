@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBlogHTML = `<!DOCTYPE html>
+<html lang="ja">
+<head><title>テックブログ</title></head>
+<body>
+  <ul class="posts">
+    <li class="post">
+      <h2 class="title">Goのジェネリクス入門</h2>
+      <a class="more" href="/posts/generics">続きを読む</a>
+    </li>
+    <li class="post">
+      <h2 class="title">
+        障害報告:
+        2026年10月15日
+      </h2>
+      <a class="more" href="posts/incident?id=42">続きを読む</a>
+    </li>
+    <li class="post">
+      <h2 class="title">外部記事</h2>
+      <a class="more" href="https://other.example.org/article">続きを読む</a>
+    </li>
+    <li class="post">
+      <h2 class="title">リンクなし</h2>
+    </li>
+  </ul>
+</body>
+</html>`
+
+const testStatusHTML = `<html>
+<head><base href="https://status.example.com/history/"></head>
+<body>
+  <a class="incident" href="2026-10-16">API latency degraded</a>
+  <a class="incident" href="2026-10-14"> Scheduled maintenance </a>
+</body>
+</html>`
+
+func TestScrapeSource_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/blog/":
+			w.Write([]byte(testBlogHTML))
+		case "/status":
+			w.Write([]byte(testStatusHTML))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("extracts items and resolves relative links", func(t *testing.T) {
+		source, err := newScrapeSource(SourceConfig{
+			Type:          "scrape",
+			Name:          "Tech Blog",
+			URL:           server.URL + "/blog/",
+			ItemSelector:  "li.post",
+			TitleSelector: "h2.title",
+			LinkSelector:  "a.more",
+		})
+		require.NoError(t, err)
+
+		items, err := source.Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []Item{
+			{Title: "Goのジェネリクス入門", Link: server.URL + "/posts/generics"},
+			{Title: "障害報告: 2026年10月15日", Link: server.URL + "/blog/posts/incident?id=42"},
+			{Title: "外部記事", Link: "https://other.example.org/article"},
+		}, items)
+	})
+
+	t.Run("uses the item itself as title and link and honours base href", func(t *testing.T) {
+		source, err := newScrapeSource(SourceConfig{Type: "scrape", URL: server.URL + "/status", ItemSelector: "a.incident"})
+		require.NoError(t, err)
+
+		items, err := source.Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []Item{
+			{Title: "API latency degraded", Link: "https://status.example.com/history/2026-10-16"},
+			{Title: "Scheduled maintenance", Link: "https://status.example.com/history/2026-10-14"},
+		}, items)
+	})
+
+	t.Run("returns error on non-200 status", func(t *testing.T) {
+		source, err := newScrapeSource(SourceConfig{Type: "scrape", URL: server.URL + "/missing", ItemSelector: "li"})
+		require.NoError(t, err)
+
+		_, err = source.Fetch(context.Background())
+		assert.EqualError(t, err, "unexpected status code: 404")
+	})
+}
+
+func TestNewScrapeSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         SourceConfig
+		expectError string
+	}{
+		{
+			name:        "requires a URL",
+			cfg:         SourceConfig{Type: "scrape", ItemSelector: "li"},
+			expectError: "scrape source requires a URL",
+		},
+		{
+			name:        "requires an item selector",
+			cfg:         SourceConfig{Type: "scrape", URL: "https://example.com"},
+			expectError: "scrape source requires an item selector",
+		},
+		{
+			name:        "rejects invalid item selector",
+			cfg:         SourceConfig{Type: "scrape", URL: "https://example.com", ItemSelector: "li[["},
+			expectError: `invalid item selector "li[["`,
+		},
+		{
+			name:        "rejects invalid title selector",
+			cfg:         SourceConfig{Type: "scrape", URL: "https://example.com", ItemSelector: "li", TitleSelector: "h2::"},
+			expectError: `invalid title selector "h2::"`,
+		},
+		{
+			name:        "rejects invalid link selector",
+			cfg:         SourceConfig{Type: "scrape", URL: "https://example.com", ItemSelector: "li", LinkSelector: ">>"},
+			expectError: `invalid link selector ">>"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newScrapeSource(tt.cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectError)
+		})
+	}
+
+	t.Run("defaults name to page host", func(t *testing.T) {
+		source, err := newScrapeSource(SourceConfig{Type: "scrape", URL: "https://status.example.com/", ItemSelector: "li"})
+		require.NoError(t, err)
+		assert.Equal(t, "status.example.com", source.Name())
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Prefixes of secret references accepted in place of a secret value in the config file
+const (
+	fileSecretPrefix = "file://"
+	envSecretPrefix  = "env://"
+)
+
+// resolveSecret returns the secret a config value refers to. file:// values are read
+// from that file and env:// values from that environment variable; anything else is literal.
+func resolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, fileSecretPrefix):
+		return readSecretFile(strings.TrimPrefix(value, fileSecretPrefix))
+	case strings.HasPrefix(value, envSecretPrefix):
+		key := strings.TrimPrefix(value, envSecretPrefix)
+		secret, ok := os.LookupEnv(key)
+		if !ok {
+			return "", fmt.Errorf("secret reference %s: environment variable %s is not set", value, key)
+		}
+		return strings.TrimSpace(secret), nil
+	default:
+		return value, nil
+	}
+}
+
+// readSecretFile reads a secret from a file that only its owner may access,
+// trimming surrounding whitespace such as the trailing newline editors add
+func readSecretFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return "", fmt.Errorf("secret file %s must only be accessible by its owner (mode %04o)", path, perm)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// secretFromEnv returns the secret from the key environment variable, or from the
+// file named by key_FILE as used with Docker and Kubernetes secrets
+func secretFromEnv(key string) (string, bool, error) {
+	value := os.Getenv(key)
+	path := os.Getenv(key + "_FILE")
+	switch {
+	case value != "" && path != "":
+		return "", false, fmt.Errorf("%s and %s_FILE are mutually exclusive", key, key)
+	case path != "":
+		secret, err := readSecretFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("%s_FILE: %w", key, err)
+		}
+		return secret, true, nil
+	case value != "":
+		return strings.TrimSpace(value), true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// maskSecret hides all but the first and last two characters of longer secrets
+func maskSecret(secret string) string {
+	if len(secret) > 4 {
+		return secret[:2] + "***" + secret[len(secret)-2:]
+	}
+	return "***"
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSecret writes a secret file with the given permissions
+func writeSecret(t *testing.T, content string, perm os.FileMode) string {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte(content), perm))
+	require.NoError(t, os.Chmod(path, perm))
+	return path
+}
+
+func TestResolveSecret(t *testing.T) {
+	t.Run("literal values are returned unchanged", func(t *testing.T) {
+		secret, err := resolveSecret("plain-token")
+		require.NoError(t, err)
+		assert.Equal(t, "plain-token", secret)
+	})
+
+	t.Run("file references are read and trimmed", func(t *testing.T) {
+		path := writeSecret(t, "  file-token\n", 0o600)
+
+		secret, err := resolveSecret("file://" + path)
+		require.NoError(t, err)
+		assert.Equal(t, "file-token", secret)
+	})
+
+	t.Run("env references are looked up and trimmed", func(t *testing.T) {
+		t.Setenv("IMAKOKO_TEST_SECRET", "env-token\n")
+
+		secret, err := resolveSecret("env://IMAKOKO_TEST_SECRET")
+		require.NoError(t, err)
+		assert.Equal(t, "env-token", secret)
+	})
+
+	t.Run("env references to unset variables fail", func(t *testing.T) {
+		_, err := resolveSecret("env://IMAKOKO_TEST_SECRET_UNSET")
+		assert.EqualError(t, err, "secret reference env://IMAKOKO_TEST_SECRET_UNSET: environment variable IMAKOKO_TEST_SECRET_UNSET is not set")
+	})
+
+	t.Run("missing files fail", func(t *testing.T) {
+		_, err := resolveSecret("file://" + filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorContains(t, err, "failed to read secret file")
+	})
+}
+
+func TestReadSecretFile_Permissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		perm        os.FileMode
+		expectError bool
+	}{
+		{name: "owner read-write", perm: 0o600},
+		{name: "owner read-only", perm: 0o400},
+		{name: "group readable", perm: 0o640, expectError: true},
+		{name: "world readable", perm: 0o644, expectError: true},
+		{name: "world writable", perm: 0o602, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeSecret(t, "token", tt.perm)
+
+			secret, err := readSecretFile(path)
+			if tt.expectError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "must only be accessible by its owner")
+				assert.Empty(t, secret)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "token", secret)
+			}
+		})
+	}
+}
+
+func TestSecretFromEnv(t *testing.T) {
+	t.Run("reads the variable", func(t *testing.T) {
+		t.Setenv("IMAKOKO_TEST_TOKEN", " env-token ")
+		t.Setenv("IMAKOKO_TEST_TOKEN_FILE", "")
+
+		secret, ok, err := secretFromEnv("IMAKOKO_TEST_TOKEN")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "env-token", secret)
+	})
+
+	t.Run("reads the _FILE variant", func(t *testing.T) {
+		t.Setenv("IMAKOKO_TEST_TOKEN", "")
+		t.Setenv("IMAKOKO_TEST_TOKEN_FILE", writeSecret(t, "file-token\n", 0o400))
+
+		secret, ok, err := secretFromEnv("IMAKOKO_TEST_TOKEN")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "file-token", secret)
+	})
+
+	t.Run("rejects both being set", func(t *testing.T) {
+		t.Setenv("IMAKOKO_TEST_TOKEN", "env-token")
+		t.Setenv("IMAKOKO_TEST_TOKEN_FILE", writeSecret(t, "file-token", 0o600))
+
+		_, _, err := secretFromEnv("IMAKOKO_TEST_TOKEN")
+		assert.EqualError(t, err, "IMAKOKO_TEST_TOKEN and IMAKOKO_TEST_TOKEN_FILE are mutually exclusive")
+	})
+
+	t.Run("reports nothing when unset", func(t *testing.T) {
+		t.Setenv("IMAKOKO_TEST_TOKEN", "")
+		t.Setenv("IMAKOKO_TEST_TOKEN_FILE", "")
+
+		secret, ok, err := secretFromEnv("IMAKOKO_TEST_TOKEN")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Empty(t, secret)
+	})
+}
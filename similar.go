@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// defaultSimilarity is the title similarity from which two items count as the same story
+const defaultSimilarity = 0.6
+
+// titleStopwords are English words too common to say anything about a title
+var titleStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "to": true, "in": true, "on": true,
+	"for": true, "and": true, "or": true, "is": true, "are": true, "with": true, "at": true,
+	"by": true, "from": true, "as": true, "its": true, "it": true, "how": true, "why": true,
+}
+
+// titleTokens splits a title into the set of tokens compared for similarity. Latin words
+// and numbers become lowercase tokens (version numbers such as 1.27 stay whole), while
+// runs of Japanese or Chinese characters, which aren't separated by spaces, become
+// character bigrams.
+func titleTokens(title string) map[string]bool {
+	tokens := make(map[string]bool)
+	runes := []rune(strings.ToLower(title))
+
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case isCJK(r):
+			j := i
+			for j < len(runes) && isCJK(runes[j]) {
+				j++
+			}
+			if j-i == 1 {
+				tokens[string(runes[i])] = true
+			}
+			for k := i; k+1 < j; k++ {
+				tokens[string(runes[k:k+2])] = true
+			}
+			i = j
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) ||
+				(runes[j] == '.' && j+1 < len(runes) && unicode.IsDigit(runes[j+1]) && unicode.IsDigit(runes[j-1]))) {
+				if isCJK(runes[j]) {
+					break
+				}
+				j++
+			}
+			if word := string(runes[i:j]); !titleStopwords[word] {
+				tokens[word] = true
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// isCJK reports whether r is a kanji, hiragana or katakana character
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+// jaccard is the share of tokens two sets have in common
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for token := range a {
+		if b[token] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// storyCluster is the items covering one story, represented by one of them
+type storyCluster struct {
+	item    Item
+	tokens  []map[string]bool
+	sources []string
+}
+
+// collapseNearDuplicates merges items from different sources whose titles are at least
+// threshold similar into one entry per story; a source's own items are never merged, as
+// similar titles there are different stories. The entry from the source with the highest priority is kept at the
+// position the story first appeared, listing the other sources in AlsoSources.
+func collapseNearDuplicates(ctx context.Context, items []Item, threshold float64, priority func(source string) int) []Item {
+	var clusters []*storyCluster
+	for _, item := range items {
+		tokens := titleTokens(item.Title)
+
+		cluster := findCluster(clusters, item.Source, tokens, threshold)
+		if cluster == nil {
+			clusters = append(clusters, &storyCluster{item: item, tokens: []map[string]bool{tokens}, sources: []string{item.Source}})
+			continue
+		}
+
+		recordDropped(ctx, dropSimilar)
+		cluster.tokens = append(cluster.tokens, tokens)
+		cluster.sources = append(cluster.sources, item.Source)
+		if priority(item.Source) > priority(cluster.item.Source) {
+			cluster.item = item
+		}
+	}
+
+	collapsed := make([]Item, len(clusters))
+	for i, cluster := range clusters {
+		item := cluster.item
+		for _, source := range cluster.sources {
+			if source != item.Source && !slices.Contains(item.AlsoSources, source) {
+				item.AlsoSources = append(item.AlsoSources, source)
+			}
+		}
+		collapsed[i] = item
+	}
+	return collapsed
+}
+
+// findCluster returns the cluster with the item most similar to tokens, if it is similar
+// enough, skipping the clusters that already have an item from source
+func findCluster(clusters []*storyCluster, source string, tokens map[string]bool, threshold float64) *storyCluster {
+	var best *storyCluster
+	bestSimilarity := threshold
+	for _, cluster := range clusters {
+		if slices.Contains(cluster.sources, source) {
+			continue
+		}
+		for _, other := range cluster.tokens {
+			if similarity := jaccard(tokens, other); similarity > bestSimilarity || (best == nil && similarity == bestSimilarity) {
+				best, bestSimilarity = cluster, similarity
+			}
+		}
+	}
+	return best
+}
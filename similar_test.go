@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tokenList(tokens map[string]bool) []string {
+	var list []string
+	for token := range tokens {
+		list = append(list, token)
+	}
+	return list
+}
+
+func TestTitleTokens(t *testing.T) {
+	assert.ElementsMatch(t, []string{"go", "1.27", "released"}, tokenList(titleTokens("Go 1.27 is Released!")))
+	assert.ElementsMatch(t, []string{"東京", "京都", "都で", "で地", "地震"}, tokenList(titleTokens("東京都で地震")))
+	assert.ElementsMatch(t, []string{"openai", "が", "gpt", "6", "を発", "発表"}, tokenList(titleTokens("OpenAIがGPT-6を発表")))
+	assert.Empty(t, titleTokens("The ... of"))
+}
+
+func TestJaccard(t *testing.T) {
+	a := titleTokens("Go 1.27 released with generic methods")
+	b := titleTokens("Go 1.27 is released, adds generic methods")
+	assert.InDelta(t, 5.0/6.0, jaccard(a, b), 0.001)
+	assert.Zero(t, jaccard(a, titleTokens("")))
+}
+
+func TestCollapseNearDuplicates(t *testing.T) {
+	items := []Item{
+		{Title: "Go 1.27 released with generic methods", Link: "https://go.dev/blog/go1.27", Source: "HN"},
+		{Title: "東京都で震度5弱の地震", Link: "https://news.example.jp/1", Source: "NHK"},
+		{Title: "Profiling with pprof", Link: "https://example.com/pprof", Source: "HN"},
+		{Title: "Go 1.27 is released, adds generic methods", Link: "https://lwn.net/go", Source: "LWN"},
+		{Title: "東京都で震度5弱の地震 けが人なし", Link: "https://news.example.jp/2", Source: "Yahoo"},
+		{Title: "Go 1.27 released with generic methods!", Link: "https://lobste.rs/s/go", Source: "Lobsters"},
+		{Title: "Go 1.28 roadmap", Link: "https://go.dev/roadmap", Source: "HN"},
+	}
+	priorities := map[string]int{"Lobsters": 5}
+	priority := func(source string) int { return priorities[source] }
+
+	ctx, dropped := withDropCounter(context.Background())
+	collapsed := collapseNearDuplicates(ctx, items, defaultSimilarity, priority)
+
+	require.Len(t, collapsed, 4)
+	assert.Equal(t, "Lobsters", collapsed[0].Source)
+	assert.Equal(t, []string{"HN", "LWN"}, collapsed[0].AlsoSources)
+	assert.Equal(t, "NHK", collapsed[1].Source)
+	assert.Equal(t, []string{"Yahoo"}, collapsed[1].AlsoSources)
+	assert.Nil(t, collapsed[2].AlsoSources)
+	assert.Equal(t, "Go 1.28 roadmap", collapsed[3].Title)
+	assert.Equal(t, []DropCount{{Reason: dropSimilar, Count: 3}}, dropped.list())
+
+	// A strict threshold only merges titles with the same words
+	strict := collapseNearDuplicates(context.Background(), items, 1, priority)
+	assert.Len(t, strict, 6)
+
+	t.Run("keeps similar titles from the same source apart", func(t *testing.T) {
+		items := []Item{
+			{Title: "Show HN: A tiny Go web framework", Link: "https://example.com/go", Source: "HN"},
+			{Title: "Show HN: A tiny Rust web framework", Link: "https://example.com/rust", Source: "HN"},
+			{Title: "Show HN: A tiny Rust web framework", Link: "https://lobste.rs/s/rust", Source: "Lobsters"},
+		}
+		ctx, dropped := withDropCounter(context.Background())
+		collapsed := collapseNearDuplicates(ctx, items, defaultSimilarity, priority)
+
+		require.Len(t, collapsed, 2)
+		assert.Equal(t, "https://example.com/go", collapsed[0].Link)
+		assert.Nil(t, collapsed[0].AlsoSources)
+		// Another source's copy joins the most similar story, not the first similar one
+		assert.Equal(t, "https://lobste.rs/s/rust", collapsed[1].Link)
+		assert.Equal(t, []string{"HN"}, collapsed[1].AlsoSources)
+		assert.Equal(t, []DropCount{{Reason: dropSimilar, Count: 1}}, dropped.list())
+	})
+}
+
+func TestHackerNewsFormatter_AlsoSources(t *testing.T) {
+	item := Item{Title: "Go 1.27 released", Link: "https://go.dev/blog/go1.27", AlsoSources: []string{"HN", "LWN"}}
+
+	msg, err := hackerNewsFormatter{localizer: newTestLocalizer(t, "en")}.renderItem(item, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1. Go 1.27 released\nhttps://go.dev/blog/go1.27\nalso: 2 more sources", msg)
+
+	msg, err = hackerNewsFormatter{localizer: newTestLocalizer(t, "ja")}.renderItem(item, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1. Go 1.27 released\nhttps://go.dev/blog/go1.27\nほか2ソースでも掲載", msg)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source is a pluggable news input collected into the digest
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context) ([]Item, error)
+}
+
+// SourceConfig describes one configured source.
+// Fields beyond Type, Name, URL and Labels only apply to the source types that use them.
+type SourceConfig struct {
+	Type   string   `yaml:"type"`
+	Name   string   `yaml:"name,omitempty"`
+	URL    string   `yaml:"url,omitempty"`
+	Labels []string `yaml:"labels,omitempty"`
+	// Priority decides which source's entry is kept when several link the same article;
+	// the highest wins and ties go to the source listed first
+	Priority int `yaml:"priority,omitempty"`
+
+	// reddit
+	Subreddit   string `yaml:"subreddit,omitempty"`
+	UserAgent   string `yaml:"user_agent,omitempty"`
+	MinScore    int    `yaml:"min_score,omitempty"`
+	MinComments int    `yaml:"min_comments,omitempty"`
+
+	// github
+	Repos              []string      `yaml:"repos,omitempty"`
+	Token              string        `yaml:"token,omitempty"`
+	IncludePrereleases bool          `yaml:"include_prereleases,omitempty"`
+	IncludeDrafts      bool          `yaml:"include_drafts,omitempty"`
+	MaxAge             time.Duration `yaml:"max_age,omitempty"`
+
+	// scrape
+	ItemSelector  string `yaml:"item_selector,omitempty"`
+	TitleSelector string `yaml:"title_selector,omitempty"`
+	LinkSelector  string `yaml:"link_selector,omitempty"`
+}
+
+// String describes the source without revealing its token
+func (s SourceConfig) String() string {
+	var target string
+	switch {
+	case s.Subreddit != "":
+		target = "r/" + strings.TrimPrefix(s.Subreddit, "r/")
+	case len(s.Repos) > 0:
+		target = strings.Join(s.Repos, ",")
+	default:
+		target = s.URL
+	}
+	desc := fmt.Sprintf("%s(%s)", s.Type, target)
+	if s.Token != "" {
+		desc += fmt.Sprintf(" token=%q", maskSecret(s.Token))
+	}
+	return desc
+}
+
+// SourceFactory builds a Source from its configuration
+type SourceFactory func(cfg SourceConfig) (Source, error)
+
+// sourceFactories maps a source type to its factory
+var sourceFactories = map[string]SourceFactory{}
+
+// RegisterSource makes a source type available to NewSources
+func RegisterSource(sourceType string, factory SourceFactory) {
+	sourceFactories[sourceType] = factory
+}
+
+// NewSources builds every configured source using the registry
+func NewSources(configs []SourceConfig) ([]Source, error) {
+	sources := make([]Source, 0, len(configs))
+	for i, cfg := range configs {
+		factory, ok := sourceFactories[cfg.Type]
+		if !ok {
+			return nil, fmt.Errorf("source %d: unknown source type %q", i+1, cfg.Type)
+		}
+		source, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("source %d (%s): %w", i+1, cfg.Type, err)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// validateSources checks every source configuration and reports all problems at once
+func validateSources(configs []SourceConfig) error {
+	if len(configs) == 0 {
+		return errors.New("at least one source is required")
+	}
+
+	var errs []error
+	for i, cfg := range configs {
+		if cfg.URL != "" && !isHTTPURL(cfg.URL) {
+			errs = append(errs, fmt.Errorf("source %d (%s): URL %q must be an absolute http(s) URL", i+1, cfg.Type, cfg.URL))
+			continue
+		}
+		factory, ok := sourceFactories[cfg.Type]
+		if !ok {
+			errs = append(errs, fmt.Errorf("source %d: unknown source type %q", i+1, cfg.Type))
+			continue
+		}
+		if _, err := factory(cfg); err != nil {
+			errs = append(errs, fmt.Errorf("source %d (%s): %w", i+1, cfg.Type, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SourceError records the failure of a single source during collection
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("source %s: %v", e.Source, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// collectNews fetches all sources concurrently and returns their items in source order.
+// Failed sources don't stop the others; their errors are joined as *SourceError values,
+// and any items a failing source still returned are kept.
+func collectNews(ctx context.Context, sources []Source) ([]Item, error) {
+	results := make([][]Item, len(sources))
+	errs := make([]error, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			items, err := source.Fetch(ctx)
+			if err != nil {
+				errs[i] = &SourceError{Source: source.Name(), Err: err}
+			}
+			for j := range items {
+				items[j].Source = source.Name()
+			}
+			results[i] = items
+		}()
+	}
+	wg.Wait()
+
+	var items []Item
+	for _, result := range results {
+		items = append(items, result...)
+	}
+	return items, errors.Join(errs...)
+}
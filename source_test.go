@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource is a Source returning canned items or an error
+type fakeSource struct {
+	name  string
+	items []Item
+	err   error
+}
+
+func (s *fakeSource) Name() string {
+	return s.name
+}
+
+func (s *fakeSource) Fetch(ctx context.Context) ([]Item, error) {
+	return s.items, s.err
+}
+
+func TestNewSources(t *testing.T) {
+	t.Run("builds registered source types", func(t *testing.T) {
+		sources, err := NewSources([]SourceConfig{
+			{Type: "rss", Name: "HN", URL: "https://hnrss.org/frontpage"},
+			{Type: "rss", URL: "https://lobste.rs/rss"},
+		})
+		require.NoError(t, err)
+		require.Len(t, sources, 2)
+		assert.Equal(t, "HN", sources[0].Name())
+		assert.Equal(t, "lobste.rs", sources[1].Name())
+	})
+
+	t.Run("rejects unknown source type", func(t *testing.T) {
+		sources, err := NewSources([]SourceConfig{{Type: "carrier-pigeon"}})
+		assert.Nil(t, sources)
+		assert.EqualError(t, err, `source 1: unknown source type "carrier-pigeon"`)
+	})
+
+	t.Run("wraps factory errors with position", func(t *testing.T) {
+		_, err := NewSources([]SourceConfig{{Type: "rss"}})
+		assert.EqualError(t, err, "source 1 (rss): rss source requires a URL")
+	})
+
+	t.Run("uses custom registered factories", func(t *testing.T) {
+		RegisterSource("fake", func(cfg SourceConfig) (Source, error) {
+			return &fakeSource{name: cfg.Name}, nil
+		})
+		defer delete(sourceFactories, "fake")
+
+		sources, err := NewSources([]SourceConfig{{Type: "fake", Name: "canned"}})
+		require.NoError(t, err)
+		assert.Equal(t, "canned", sources[0].Name())
+	})
+}
+
+func TestCollectNews(t *testing.T) {
+	t.Run("collects items from all sources in order", func(t *testing.T) {
+		sources := []Source{
+			&fakeSource{name: "HN", items: []Item{{Title: "a"}, {Title: "b"}}},
+			&fakeSource{name: "Lobsters", items: []Item{{Title: "c"}}},
+		}
+
+		items, err := collectNews(context.Background(), sources)
+		require.NoError(t, err)
+		assert.Equal(t, []Item{
+			{Title: "a", Source: "HN"},
+			{Title: "b", Source: "HN"},
+			{Title: "c", Source: "Lobsters"},
+		}, items)
+	})
+
+	t.Run("carries per-source errors and keeps other items", func(t *testing.T) {
+		fetchErr := errors.New("connection refused")
+		sources := []Source{
+			&fakeSource{name: "HN", err: fetchErr},
+			&fakeSource{name: "Lobsters", items: []Item{{Title: "c"}}},
+		}
+
+		items, err := collectNews(context.Background(), sources)
+		require.Error(t, err)
+		assert.Equal(t, []Item{{Title: "c", Source: "Lobsters"}}, items)
+		assert.ErrorIs(t, err, fetchErr)
+
+		var sourceErr *SourceError
+		require.ErrorAs(t, err, &sourceErr)
+		assert.Equal(t, "HN", sourceErr.Source)
+		assert.EqualError(t, sourceErr, "source HN: connection refused")
+	})
+
+	t.Run("keeps partial items from a failing source", func(t *testing.T) {
+		sources := []Source{
+			&fakeSource{name: "GitHub", items: []Item{{Title: "a"}}, err: errors.New("golang/go: unexpected status code: 404")},
+		}
+
+		items, err := collectNews(context.Background(), sources)
+		assert.EqualError(t, err, "source GitHub: golang/go: unexpected status code: 404")
+		assert.Equal(t, []Item{{Title: "a", Source: "GitHub"}}, items)
+	})
+
+	t.Run("no sources returns no items", func(t *testing.T) {
+		items, err := collectNews(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+}
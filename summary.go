@@ -0,0 +1,228 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Summary defaults, used for settings left at zero
+const (
+	defaultSummarySentences   = 2
+	defaultSummaryLength      = 200
+	defaultSummaryTimeout     = 10 * time.Second
+	defaultSummaryMaxBytes    = 2 * 1024 * 1024
+	defaultSummaryConcurrency = 4
+)
+
+// SummaryConfig enables short extractive summaries of the linked articles, along with
+// their reading time. Zero values take the defaults.
+type SummaryConfig struct {
+	// Sentences is how many sentences the summary has, 1 or 2
+	Sentences int `yaml:"sentences"`
+	// MaxLength caps the summary in characters
+	MaxLength int `yaml:"max_length"`
+	// Timeout bounds fetching a single article
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxBytes is how much of an article page is read
+	MaxBytes int64 `yaml:"max_bytes"`
+	// Concurrency is how many articles are fetched at once
+	Concurrency int `yaml:"concurrency"`
+}
+
+func (c SummaryConfig) validate() error {
+	var errs []error
+	if c.Sentences < 0 || c.Sentences > 2 {
+		errs = append(errs, fmt.Errorf("summary.sentences %d must be 1 or 2", c.Sentences))
+	}
+	if c.MaxLength < 0 || c.Timeout < 0 || c.MaxBytes < 0 || c.Concurrency < 0 {
+		errs = append(errs, errors.New("summary limits must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// withDefaults fills in the settings left at zero
+func (c SummaryConfig) withDefaults() SummaryConfig {
+	if c.Sentences == 0 {
+		c.Sentences = defaultSummarySentences
+	}
+	if c.MaxLength == 0 {
+		c.MaxLength = defaultSummaryLength
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultSummaryTimeout
+	}
+	if c.MaxBytes == 0 {
+		c.MaxBytes = defaultSummaryMaxBytes
+	}
+	if c.Concurrency == 0 {
+		c.Concurrency = defaultSummaryConcurrency
+	}
+	return c
+}
+
+// Sentences outside this length range in characters don't make good summaries
+const (
+	minSentenceLength = 20
+	maxSentenceLength = 400
+)
+
+// maxRankedSentences caps the sentences TextRank compares, as its cost grows with the
+// square of their number; an article's point is made well before the end of a long page
+const maxRankedSentences = 150
+
+// summarizeText picks the n most central sentences of text with TextRank and returns
+// them in their original order. Only the first maxRankedSentences eligible sentences
+// are considered.
+func summarizeText(text string, n int) string {
+	var sentences []string
+	for _, s := range splitSentences(text) {
+		if length := utf8.RuneCountInString(s); length >= minSentenceLength && length <= maxSentenceLength {
+			sentences = append(sentences, s)
+			if len(sentences) == maxRankedSentences {
+				break
+			}
+		}
+	}
+	if len(sentences) == 0 {
+		return ""
+	}
+
+	scores := textRank(sentences)
+	order := make([]int, len(sentences))
+	for i := range order {
+		order[i] = i
+	}
+	// Ties go to the earlier sentence, which tends to introduce the topic
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(scores[b], scores[a])
+	})
+	picked := order[:min(n, len(order))]
+	slices.Sort(picked)
+
+	summary := make([]string, len(picked))
+	for i, idx := range picked {
+		summary[i] = sentences[idx]
+	}
+	return joinSentences(summary)
+}
+
+// splitSentences breaks text at sentence-ending punctuation and line breaks.
+// Latin full stops only end a sentence before whitespace, so 1.27 or e.g. stay whole.
+func splitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			sentences = append(sentences, s)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+		switch r {
+		case '。', '！', '？':
+			flush()
+		case '.', '!', '?':
+			if i+1 == len(runes) || unicode.IsSpace(runes[i+1]) {
+				flush()
+			}
+		}
+	}
+	flush()
+	return sentences
+}
+
+// joinSentences puts a space between sentences unless they end in Japanese punctuation
+func joinSentences(sentences []string) string {
+	var b strings.Builder
+	for i, s := range sentences {
+		if i > 0 {
+			if last, _ := utf8.DecodeLastRuneInString(sentences[i-1]); !strings.ContainsRune("。！？", last) {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+// textRank scores sentences by PageRank over a graph weighted by the tokens they share,
+// normalized by sentence length as in the original TextRank paper
+func textRank(sentences []string) []float64 {
+	const (
+		damping    = 0.85
+		iterations = 50
+		tolerance  = 1e-6
+	)
+
+	n := len(sentences)
+	tokens := make([]map[string]bool, n)
+	for i, s := range sentences {
+		tokens[i] = titleTokens(s)
+	}
+
+	weights := make([][]float64, n)
+	outSum := make([]float64, n)
+	for i := range weights {
+		weights[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			w := sentenceSimilarity(tokens[i], tokens[j])
+			weights[i][j], weights[j][i] = w, w
+			outSum[i] += w
+			outSum[j] += w
+		}
+	}
+
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = 1
+	}
+	for range iterations {
+		next := make([]float64, n)
+		delta := 0.0
+		for i := 0; i < n; i++ {
+			sum := 0.0
+			for j := 0; j < n; j++ {
+				if weights[j][i] > 0 {
+					sum += weights[j][i] / outSum[j] * scores[j]
+				}
+			}
+			next[i] = (1 - damping) + damping*sum
+			delta += math.Abs(next[i] - scores[i])
+		}
+		scores = next
+		if delta < tolerance {
+			break
+		}
+	}
+	return scores
+}
+
+// sentenceSimilarity is the TextRank edge weight: shared tokens over the log lengths
+func sentenceSimilarity(a, b map[string]bool) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+	common := 0
+	for token := range a {
+		if b[token] {
+			common++
+		}
+	}
+	return float64(common) / (math.Log(float64(len(a))) + math.Log(float64(len(b))))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSentences(t *testing.T) {
+	assert.Equal(t, []string{
+		"Go 1.27 is out.",
+		"It adds generic methods, e.g.for containers!",
+		"Really?",
+		"東京都は新しいアプリを発表した。",
+		"配信は16日から。",
+		"Trailing text",
+	}, splitSentences("Go 1.27 is out. It adds generic methods, e.g.for containers! Really?\n東京都は新しいアプリを発表した。配信は16日から。Trailing text"))
+}
+
+func TestSummarizeText(t *testing.T) {
+	text := strings.Join([]string{
+		"The Rust compiler release adds faster incremental builds.",
+		"Our office dog enjoyed a long walk in the park today.",
+		"The Rust compiler now caches incremental builds on disk.",
+		"Faster builds make the Rust compiler nicer to use daily.",
+		"Too short.",
+	}, " ")
+
+	assert.Equal(t,
+		"The Rust compiler release adds faster incremental builds. The Rust compiler now caches incremental builds on disk. Faster builds make the Rust compiler nicer to use daily.",
+		summarizeText(text, 3))
+	assert.Equal(t, "", summarizeText("Too short.", 2))
+
+	t.Run("Japanese sentences are joined without spaces", func(t *testing.T) {
+		summary := summarizeText("東京都は防災アプリの配信を始めたと発表しました。"+
+			"今日の天気は晴れで、気温は二十度になる見込みです。"+
+			"防災アプリは地震や大雨の情報を配信するということです。", 2)
+		assert.Equal(t, "東京都は防災アプリの配信を始めたと発表しました。防災アプリは地震や大雨の情報を配信するということです。", summary)
+	})
+
+	t.Run("only ranks the first sentences of a long page", func(t *testing.T) {
+		// Sentences sharing no words tie, so the first one wins unless the closely related
+		// sentences at the end are ranked too
+		var b strings.Builder
+		for i := range maxRankedSentences {
+			fmt.Fprintf(&b, "Alpha%d bravo%d charlie%d delta%d echo%d. ", i, i, i, i, i)
+		}
+		b.WriteString("The compiler cache makes incremental builds faster. The compiler cache keeps incremental builds on disk.")
+
+		assert.Equal(t, "Alpha0 bravo0 charlie0 delta0 echo0.", summarizeText(b.String(), 1))
+	})
+}
+
+func TestFetchArticles_Summary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			http.ServeFile(w, r, "testdata/article.html")
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("<p>Far too late to be part of the digest, sadly for everyone.</p>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	items := []Item{
+		{Title: "Go 1.27 is released", Link: server.URL + "/article"},
+		{Title: "Gone", Link: server.URL + "/missing", Source: "HN"},
+		{Title: "Slow", Link: server.URL + "/slow", Source: "HN"},
+		{Title: "Ask HN: no link"},
+	}
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, LogConfig{Format: "json"})
+	require.NoError(t, err)
+
+	fetchArticles(withLogger(context.Background(), logger), items, nil, &SummaryConfig{Sentences: 1, MaxLength: 60, Timeout: 50 * time.Millisecond})
+
+	assert.Equal(t, "The Go team is happy to announce the release of Go 1.27, wh…", items[0].Summary)
+	assert.Empty(t, items[1].Summary)
+	assert.Empty(t, items[2].Summary)
+	assert.Empty(t, items[3].Summary)
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Equal(t, "failed to fetch article", line["msg"])
+		assert.Equal(t, "HN", line["source"])
+	}
+}
+
+func TestSummaryConfig_Validate(t *testing.T) {
+	require.NoError(t, SummaryConfig{}.validate())
+	assert.EqualError(t, SummaryConfig{Sentences: 3, Timeout: -time.Second}.validate(),
+		"summary.sentences 3 must be 1 or 2\nsummary limits must not be negative")
+}
+
+func TestHackerNewsFormatter_Summary(t *testing.T) {
+	item := Item{Title: "Go 1.27 released", Link: "https://go.dev/blog/go1.27", Summary: "Generic methods arrive.", AlsoSources: []string{"LWN"}}
+	f := hackerNewsFormatter{localizer: newTestLocalizer(t, "en")}
+
+	msg, err := f.renderItem(item, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1. Go 1.27 released\nhttps://go.dev/blog/go1.27\nGeneric methods arrive.\nalso: 1 more source", msg)
+
+	item.Summary = strings.Repeat("長い要約。", 2000)
+	msg, err = f.renderItem(item, 1, 1)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(msg), MaxMessageLength)
+	assert.True(t, strings.HasSuffix(msg, "…\nalso: 1 more source"))
+}
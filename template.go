@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultItemTemplate matches the layout of hackerNewsFormatter: the summary, or else the
+// page description, and the sources that also covered the story follow the link
+const defaultItemTemplate = "{{.Number}}. {{markers .Item}}{{.Title}}\n{{with .OriginalTitle}}{{.}}\n{{end}}" +
+	"{{.Link}}{{with .ReadingMinutes}} ・ {{readtime .}}{{end}}" +
+	"{{with or .Summary .OpenGraph.Description}}\n{{.}}{{end}}{{with .AlsoSources}}\n{{also (len .)}}{{end}}"
+
+// FormatConfig holds the text/template sources for custom message layouts
+// and switches for the built-in digest summary messages
+type FormatConfig struct {
+	Template string `yaml:"template"`
+	Header   string `yaml:"header"`
+	Footer   string `yaml:"footer"`
+
+	// SummaryHeader leads the digest with its date, item count and sources;
+	// SummaryFooter ends it with how many items were filtered out and why
+	SummaryHeader bool `yaml:"summary_header"`
+	SummaryFooter bool `yaml:"summary_footer"`
+
+	// GroupBy packs the items into sections by source, domain or category
+	// instead of sending one message each; Categories assigns the categories
+	GroupBy    string         `yaml:"group_by"`
+	Categories []CategoryRule `yaml:"categories"`
+}
+
+// enabled reports whether any template is configured
+func (c FormatConfig) enabled() bool {
+	return c.Template != "" || c.Header != "" || c.Footer != ""
+}
+
+// extraMessages counts the header and footer messages sent around the items
+func (c FormatConfig) extraMessages() int {
+	n := 0
+	for _, enabled := range []bool{c.Header != "", c.Footer != "", c.SummaryHeader, c.SummaryFooter} {
+		if enabled {
+			n++
+		}
+	}
+	return n
+}
+
+// itemTemplateData is what the item template sees: the Item's fields plus its position
+type itemTemplateData struct {
+	Item
+	Number int
+	Total  int
+}
+
+// templateFormatter implements Formatter with user-supplied text/templates.
+// Every item becomes one message unless body lays them out, for example in sections;
+// the optional header and footer become their own messages.
+type templateFormatter struct {
+	item   *template.Template
+	header *template.Template
+	footer *template.Template
+	body   Formatter
+	now    func() time.Time
+}
+
+// newTemplateFormatter parses the configured templates and checks them against a sample digest,
+// so mistakes such as unknown fields surface when the config is loaded rather than at send time.
+// The date and reltime helpers render in the locale of l.
+func newTemplateFormatter(cfg FormatConfig, l *Localizer) (*templateFormatter, error) {
+	f := &templateFormatter{now: time.Now}
+	now := func() time.Time { return f.now() }
+
+	source := cfg.Template
+	if source == "" {
+		source = defaultItemTemplate
+	}
+	var err error
+	if f.item, err = parseMessageTemplate("template", source, now, l); err != nil {
+		return nil, err
+	}
+	if cfg.Header != "" {
+		if f.header, err = parseMessageTemplate("header", cfg.Header, now, l); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Footer != "" {
+		if f.footer, err = parseMessageTemplate("footer", cfg.Footer, now, l); err != nil {
+			return nil, err
+		}
+	}
+
+	sample := &Digest{
+		Items: []Item{{Title: "Sample", Link: "https://example.com/", Source: "sample", Published: time.Now()}},
+		Date:  time.Now(),
+	}
+	if _, err := f.Format(sample); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func parseMessageTemplate(name, source string, now func() time.Time, l *Localizer) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs(now, l)).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func (f *templateFormatter) Format(d *Digest) ([]string, error) {
+	var messages []string
+
+	if f.header != nil {
+		msg, err := renderTemplate(f.header, d)
+		if err != nil {
+			return nil, err
+		}
+		messages = appendNonEmpty(messages, msg)
+	}
+
+	if f.body != nil {
+		body, err := f.body.Format(d)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, body...)
+	} else {
+		for i, item := range d.Items {
+			msg, err := f.renderItem(item, i+1, len(d.Items))
+			if err != nil {
+				return nil, err
+			}
+			messages = appendNonEmpty(messages, msg)
+		}
+	}
+
+	if f.footer != nil {
+		msg, err := renderTemplate(f.footer, d)
+		if err != nil {
+			return nil, err
+		}
+		messages = appendNonEmpty(messages, msg)
+	}
+	return messages, nil
+}
+
+// renderItem implements itemRenderer with the item template. As in the built-in layout,
+// the summary and page description give way so the message stays within LINE's limit.
+func (f *templateFormatter) renderItem(item Item, number, total int) (string, error) {
+	data := itemTemplateData{Item: item, Number: number, Total: total}
+	msg, err := renderTemplate(f.item, data)
+	if err != nil || len(msg) <= MaxMessageLength {
+		return msg, err
+	}
+
+	over := len(msg) - MaxMessageLength
+	shorten := func(s string) string {
+		if room := len(s) - over; room > 0 {
+			return truncateBytes(s, room)
+		}
+		return ""
+	}
+	data.Summary = shorten(item.Summary)
+	data.OpenGraph.Description = shorten(item.OpenGraph.Description)
+	if msg, err = renderTemplate(f.item, data); err != nil {
+		return "", err
+	}
+	return truncateBytes(msg, MaxMessageLength), nil
+}
+
+func renderTemplate(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", tmpl.Name(), err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// appendNonEmpty skips messages a template rendered as blank, which LINE would reject
+func appendNonEmpty(messages []string, msg string) []string {
+	if msg == "" {
+		return messages
+	}
+	return append(messages, msg)
+}
+
+// templateFuncs are the helpers available to message templates
+func templateFuncs(now func() time.Time, l *Localizer) template.FuncMap {
+	return template.FuncMap{
+		"domain":   linkDomain,
+		"truncate": truncateText,
+		"reltime": func(t time.Time) string {
+			return l.RelativeTime(t, now())
+		},
+		"date":     l.Date,
+		"escape":   escapeText,
+		"markers":  itemMarkers,
+		"readtime": l.ReadingTime,
+		"also":     l.AlsoSources,
+	}
+}
+
+// linkDomain returns the host of link without a leading "www."
+func linkDomain(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// truncateText shortens s to at most n characters, marking the cut with an ellipsis.
+// The length comes first so it reads naturally in a pipeline: {{.Title | truncate 40}}
+func truncateText(n int, s string) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+// escapeText collapses newlines, tabs and repeated spaces so a value
+// taken from a feed can't break the layout of a message
+func escapeText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
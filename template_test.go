@@ -0,0 +1,160 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTemplateFormatter(t *testing.T, cfg FormatConfig, locale string) *templateFormatter {
+	f, err := newTemplateFormatter(cfg, newTestLocalizer(t, locale))
+	require.NoError(t, err)
+
+	f.now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	return f
+}
+
+func TestTemplateFormatter_Format(t *testing.T) {
+	items := []Item{
+		{Title: "Go 1.27 is released", Link: "https://www.go.dev/blog/go1.27", Source: "HN", Score: 912, Published: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{Title: "A very long title about\nprofiling allocations", Link: "https://example.dev/posts/pprof", Source: "r/golang", Score: 87, Published: time.Date(2026, 10, 16, 11, 45, 0, 0, time.UTC)},
+	}
+
+	t.Run("default item template matches FormatHackerNews", func(t *testing.T) {
+		f := newTestTemplateFormatter(t, FormatConfig{}, "ja")
+
+		messages, err := f.Format(&Digest{Items: items})
+		require.NoError(t, err)
+		assert.Equal(t, FormatHackerNews(items), messages)
+
+		annotated := []Item{
+			{Title: "Go 1.27 リリース", OriginalTitle: "Go 1.27 is released", Link: "https://go.dev/blog/go1.27", ReadingMinutes: 4},
+			{Title: "Markets today", Link: "https://www.nikkei.com/article/1", Paywalled: true, LinkBroken: true},
+		}
+		messages, err = f.Format(&Digest{Items: annotated})
+		require.NoError(t, err)
+		assert.Equal(t, FormatHackerNews(annotated), messages)
+	})
+
+	t.Run("default item template matches the built-in layout", func(t *testing.T) {
+		f := newTestTemplateFormatter(t, FormatConfig{Header: "{{len .Items}}件"}, "ja")
+		enriched := []Item{
+			{Title: "Go 1.27 is released", Link: "https://go.dev/blog/go1.27", Summary: "The Go team released Go 1.27.", AlsoSources: []string{"Lobsters", "r/golang"}},
+			{Title: "Profiling with pprof", Link: "https://example.dev/posts/pprof", OpenGraph: OpenGraph{Description: "A guide to pprof."}, ReadingMinutes: 6},
+		}
+
+		messages, err := f.Format(&Digest{Items: enriched})
+		require.NoError(t, err)
+		builtin, err := hackerNewsFormatter{localizer: newTestLocalizer(t, "ja")}.Format(&Digest{Items: enriched})
+		require.NoError(t, err)
+		assert.Equal(t, append([]string{"2件"}, builtin...), messages)
+	})
+
+	t.Run("shortens summaries to the message limit", func(t *testing.T) {
+		f := newTestTemplateFormatter(t, FormatConfig{Template: "{{.Title}}\n{{.Summary}}\n{{.Link}}"}, "en")
+		long := Item{Title: "Long read", Link: "https://example.com/long", Summary: strings.Repeat("word ", MaxMessageLength)}
+
+		messages, err := f.Format(&Digest{Items: []Item{long}})
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.LessOrEqual(t, len(messages[0]), MaxMessageLength)
+		assert.True(t, strings.HasSuffix(messages[0], "…\nhttps://example.com/long"), "the summary gives way, not the link")
+	})
+
+	t.Run("renders items with helpers", func(t *testing.T) {
+		f := newTestTemplateFormatter(t, FormatConfig{
+			Template: "📰 {{.Number}}/{{.Total}} {{.Title | escape | truncate 30}}\n{{domain .Link}} ・ {{.Score}} points ・ {{reltime .Published}}\n{{.Link}}",
+		}, "en")
+
+		messages, err := f.Format(&Digest{Items: items})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"📰 1/2 Go 1.27 is released\ngo.dev ・ 912 points ・ 3h ago\nhttps://www.go.dev/blog/go1.27",
+			"📰 2/2 A very long title about profi…\nexample.dev ・ 87 points ・ 15m ago\nhttps://example.dev/posts/pprof",
+		}, messages)
+	})
+
+	t.Run("adds header and footer messages", func(t *testing.T) {
+		f := newTestTemplateFormatter(t, FormatConfig{
+			Header: "{{date .Date}}のニュース: {{len .Items}}件\n",
+			Footer: "{{if gt (len .Items) 1}}以上です{{end}}",
+		}, "ja")
+
+		messages, err := f.Format(&Digest{Items: items, Date: time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		require.Len(t, messages, 4)
+		assert.Equal(t, "10月16日(金)のニュース: 2件", messages[0])
+		assert.Equal(t, "以上です", messages[3])
+	})
+
+	t.Run("skips messages rendered blank", func(t *testing.T) {
+		f := newTestTemplateFormatter(t, FormatConfig{
+			Template: "{{if ge .Score 100}}{{.Title}}{{end}}",
+			Footer:   "  \n",
+		}, "ja")
+
+		messages, err := f.Format(&Digest{Items: items})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Go 1.27 is released"}, messages)
+	})
+}
+
+func TestNewTemplateFormatter_Validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         FormatConfig
+		expectError string
+	}{
+		{
+			name:        "syntax error in item template",
+			cfg:         FormatConfig{Template: "{{.Title"},
+			expectError: "invalid template: template: template:1: unclosed action",
+		},
+		{
+			name:        "unknown function in header",
+			cfg:         FormatConfig{Header: "{{shout .Date}}"},
+			expectError: `invalid header: template: header:1: function "shout" not defined`,
+		},
+		{
+			name:        "unknown item field is caught at load time",
+			cfg:         FormatConfig{Template: "{{.Headline}}"},
+			expectError: "failed to render template",
+		},
+		{
+			name:        "unknown digest field in footer is caught at load time",
+			cfg:         FormatConfig{Footer: "{{.Total}}"},
+			expectError: "failed to render footer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter, err := newTemplateFormatter(tt.cfg, newTestLocalizer(t, "ja"))
+			assert.Nil(t, formatter)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectError)
+		})
+	}
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	t.Run("domain", func(t *testing.T) {
+		assert.Equal(t, "go.dev", linkDomain("https://www.go.dev/blog"))
+		assert.Equal(t, "news.ycombinator.com", linkDomain("https://news.ycombinator.com:443/item?id=1"))
+		assert.Equal(t, "", linkDomain("::not a url"))
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		assert.Equal(t, "short", truncateText(10, "short"))
+		assert.Equal(t, "exactly10!", truncateText(10, "exactly10!"))
+		assert.Equal(t, "日本語の…", truncateText(5, "日本語のタイトル"))
+		assert.Equal(t, "unchanged", truncateText(0, "unchanged"))
+	})
+
+	t.Run("escape", func(t *testing.T) {
+		assert.Equal(t, "Title With Newlines", escapeText("Title\nWith\t  Newlines\n"))
+	})
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Translation defaults, used for settings left at zero
+const (
+	defaultTranslationTimeout  = 30 * time.Second
+	defaultTranslationCacheTTL = 30 * 24 * time.Hour
+)
+
+// Translator translates text between two languages given as ISO 639-1 codes
+type Translator interface {
+	Translate(ctx context.Context, text, source, target string) (string, error)
+}
+
+// TranslationConfig enables translating English titles for the targets whose locale
+// is the target language. Zero values take the defaults.
+type TranslationConfig struct {
+	// Endpoint is the URL of a LibreTranslate-compatible /translate API
+	Endpoint string `yaml:"endpoint"`
+	// APIKey is sent with every request when set; file:// and env:// references are resolved
+	APIKey string `yaml:"api_key"`
+	// Target is the locale titles are translated for, ja when empty
+	Target string `yaml:"target"`
+	// ShowOriginal keeps the English title under the translated one
+	ShowOriginal bool `yaml:"show_original"`
+	// Timeout bounds translating all titles of one digest
+	Timeout time.Duration `yaml:"timeout"`
+	// CacheDir keeps translations for CacheTTL; empty uses the user cache directory
+	CacheDir string        `yaml:"cache_dir"`
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// NoCache translates every title on every run
+	NoCache bool `yaml:"no_cache"`
+}
+
+func (c TranslationConfig) validate() error {
+	var errs []error
+	if !isHTTPURL(c.Endpoint) {
+		errs = append(errs, fmt.Errorf("translation.endpoint %q must be an absolute http(s) URL", c.Endpoint))
+	}
+	if err := validateLocale(c.Target); err != nil {
+		errs = append(errs, fmt.Errorf("translation.target: %w", err))
+	}
+	if c.Timeout < 0 || c.CacheTTL < 0 {
+		errs = append(errs, errors.New("translation limits must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// withDefaults fills in the settings left at zero
+func (c TranslationConfig) withDefaults() TranslationConfig {
+	if c.Target == "" {
+		c.Target = defaultLocale
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultTranslationTimeout
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = defaultTranslationCacheTTL
+	}
+	if c.CacheDir == "" && !c.NoCache {
+		if dir, err := os.UserCacheDir(); err == nil {
+			c.CacheDir = filepath.Join(dir, "imakoko", "translations")
+		}
+	}
+	return c
+}
+
+// newTranslator builds the HTTP translator of cfg behind its cache
+func newTranslator(cfg TranslationConfig) Translator {
+	var translator Translator = NewHTTPTranslator(&http.Client{}, cfg.Endpoint, cfg.APIKey)
+	if !cfg.NoCache && cfg.CacheDir != "" {
+		translator = cachedTranslator{Translator: translator, cache: newFileCache(cfg.CacheDir, cfg.CacheTTL)}
+	}
+	return translator
+}
+
+// HTTPTranslator implements Translator with a LibreTranslate-compatible API
+type HTTPTranslator struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+}
+
+// NewHTTPTranslator creates a translator posting to the LibreTranslate-compatible API at
+// endpoint, sending apiKey with every request when it is set
+func NewHTTPTranslator(client *http.Client, endpoint, apiKey string) *HTTPTranslator {
+	return &HTTPTranslator{client: client, endpoint: endpoint, apiKey: apiKey}
+}
+
+type translateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type translateResponse struct {
+	TranslatedText string `json:"translatedText"`
+	Error          string `json:"error"`
+}
+
+func (t *HTTPTranslator) Translate(ctx context.Context, text, source, target string) (string, error) {
+	body, err := json.Marshal(translateRequest{Q: text, Source: source, Target: target, Format: "text", APIKey: t.apiKey})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to make NewRequest: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to translate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("error reading response: %w", err)
+	}
+	var result translateResponse
+	if err := json.Unmarshal(data, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("error parsing response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != "" {
+			return "", fmt.Errorf("unexpected status code: %d: %s", resp.StatusCode, result.Error)
+		}
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if strings.TrimSpace(result.TranslatedText) == "" {
+		return "", errors.New("empty translation")
+	}
+	return strings.TrimSpace(result.TranslatedText), nil
+}
+
+// cachedTranslator remembers translations by a hash of the languages and the text
+type cachedTranslator struct {
+	Translator
+	cache *fileCache
+}
+
+func (t cachedTranslator) Translate(ctx context.Context, text, source, target string) (string, error) {
+	key := source + ">" + target + "\n" + text
+	var translation string
+	if t.cache.get(key, &translation) {
+		return translation, nil
+	}
+	translation, err := t.Translator.Translate(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	if err := t.cache.put(key, translation); err != nil {
+		loggerFrom(ctx).Warn("failed to cache translation", "error", err)
+	}
+	return translation, nil
+}
+
+// translateDigest returns d with its English titles translated when translation targets
+// locale, or d itself otherwise. Digests are translated once per locale, before formatting,
+// so targets sharing a locale don't translate every title again.
+func translateDigest(ctx context.Context, cfg *Config, locale string, d *Digest) *Digest {
+	if cfg.Translation == nil {
+		return d
+	}
+	translation := cfg.Translation.withDefaults()
+	if translation.Target != locale {
+		return d
+	}
+	return translateTitles(ctx, d, newTranslator(translation), translation)
+}
+
+// translateTitles returns a copy of d with the titles detected as English translated to
+// cfg.Target; titles in other languages are left as they are.
+// Titles that fail to translate stay in English; with ShowOriginal the English title is
+// kept as OriginalTitle.
+func translateTitles(ctx context.Context, d *Digest, translator Translator, cfg TranslationConfig) *Digest {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	translated := *d
+	translated.Items = make([]Item, len(d.Items))
+	for i, item := range d.Items {
+		if item.Lang == langEnglish {
+			title, err := translator.Translate(ctx, item.Title, langEnglish, cfg.Target)
+			if err != nil {
+				loggerFrom(ctx).Warn("failed to translate title", "title", item.Title, "locale", cfg.Target, "error", err)
+			} else {
+				if cfg.ShowOriginal {
+					item.OriginalTitle = item.Title
+				}
+				item.Title = title
+			}
+		}
+		translated.Items[i] = item
+	}
+	return &translated
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTranslator translates from a fixed table and counts its calls
+type fakeTranslator struct {
+	translations map[string]string
+	calls        int
+}
+
+func (t *fakeTranslator) Translate(_ context.Context, text, source, target string) (string, error) {
+	t.calls++
+	translation, ok := t.translations[text]
+	if !ok {
+		return "", errors.New("no translation")
+	}
+	return translation, nil
+}
+
+func newTranslateServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req translateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.APIKey != "" && req.APIKey != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"Invalid API key"}`))
+			return
+		}
+		assert.Equal(t, "en", req.Source)
+		assert.Equal(t, "ja", req.Target)
+		assert.Equal(t, "text", req.Format)
+		json.NewEncoder(w).Encode(translateResponse{TranslatedText: "翻訳: " + req.Q})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPTranslator(t *testing.T) {
+	server := newTranslateServer(t)
+
+	t.Run("translates text", func(t *testing.T) {
+		translator := NewHTTPTranslator(server.Client(), server.URL, "secret")
+		translation, err := translator.Translate(context.Background(), "Go 1.27 released", "en", "ja")
+		require.NoError(t, err)
+		assert.Equal(t, "翻訳: Go 1.27 released", translation)
+	})
+
+	t.Run("returns the API error", func(t *testing.T) {
+		translator := NewHTTPTranslator(server.Client(), server.URL, "wrong")
+		_, err := translator.Translate(context.Background(), "Go 1.27 released", "en", "ja")
+		assert.EqualError(t, err, "unexpected status code: 403: Invalid API key")
+	})
+}
+
+func TestCachedTranslator(t *testing.T) {
+	fake := &fakeTranslator{translations: map[string]string{"Hello": "こんにちは"}}
+	translator := cachedTranslator{Translator: fake, cache: newFileCache(t.TempDir(), time.Hour)}
+
+	for range 2 {
+		translation, err := translator.Translate(context.Background(), "Hello", "en", "ja")
+		require.NoError(t, err)
+		assert.Equal(t, "こんにちは", translation)
+	}
+	assert.Equal(t, 1, fake.calls)
+
+	_, err := translator.Translate(context.Background(), "Goodbye", "en", "ja")
+	assert.Error(t, err)
+	_, err = translator.Translate(context.Background(), "Goodbye", "en", "ja")
+	assert.Error(t, err)
+	assert.Equal(t, 3, fake.calls, "failures aren't cached")
+}
+
+func TestTranslateTitles(t *testing.T) {
+	d := &Digest{Items: []Item{
+		{Title: "Go 1.27 released", Link: "https://go.dev/blog/go1.27"},
+		{Title: "東京都が新アプリ", Link: "https://example.jp/1"},
+		{Title: "Untranslatable", Link: "https://example.com/2"},
+		{Title: "Neue Funktionen in Go 1.27", Link: "https://example.de/3"},
+	}}
+	tagLanguages(d.Items)
+	fake := &fakeTranslator{translations: map[string]string{"Go 1.27 released": "Go 1.27 リリース"}}
+
+	t.Run("replaces English titles", func(t *testing.T) {
+		translated := translateTitles(context.Background(), d, fake, TranslationConfig{Target: "ja", Timeout: time.Second})
+
+		messages, err := hackerNewsFormatter{}.Format(translated)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"1. Go 1.27 リリース\nhttps://go.dev/blog/go1.27",
+			"2. 東京都が新アプリ\nhttps://example.jp/1",
+			"3. Untranslatable\nhttps://example.com/2",
+			"4. Neue Funktionen in Go 1.27\nhttps://example.de/3",
+		}, messages)
+		assert.Equal(t, 2, fake.calls, "only the English titles are sent")
+		assert.Equal(t, "Go 1.27 released", d.Items[0].Title, "the digest is left as it was")
+	})
+
+	t.Run("keeps the original title when asked", func(t *testing.T) {
+		translated := translateTitles(context.Background(), d, fake, TranslationConfig{Target: "ja", ShowOriginal: true, Timeout: time.Second})
+
+		messages, err := hackerNewsFormatter{}.Format(translated)
+		require.NoError(t, err)
+		assert.Equal(t, "1. Go 1.27 リリース\nGo 1.27 released\nhttps://go.dev/blog/go1.27", messages[0])
+		assert.Equal(t, "3. Untranslatable\nhttps://example.com/2", messages[2])
+	})
+}
+
+func TestTranslateDigest(t *testing.T) {
+	server := newTranslateServer(t)
+	cfg := &Config{Translation: &TranslationConfig{Endpoint: server.URL, NoCache: true}}
+	d := &Digest{Items: []Item{{Title: "Go 1.27 released", Link: "https://go.dev/blog/go1.27", Lang: langEnglish}}}
+
+	messages, err := formatMessages(cfg, "ja", translateDigest(context.Background(), cfg, "ja", d))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1. 翻訳: Go 1.27 released\nhttps://go.dev/blog/go1.27"}, messages)
+
+	assert.Same(t, d, translateDigest(context.Background(), cfg, "en", d), "only the target locale is translated")
+}
+
+func TestDeliverDigest_TranslatesOncePerLocale(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(translateResponse{TranslatedText: "翻訳"})
+	}))
+	defer server.Close()
+	cfg := &Config{
+		DryRun:       true,
+		TargetUserID: testUserID,
+		Targets: []TargetConfig{
+			{UserID: "C0123456789abcdef0123456789abcdef"},
+			{UserID: "R0123456789abcdef0123456789abcdef", Locale: "en"},
+		},
+		Translation: &TranslationConfig{Endpoint: server.URL, NoCache: true},
+	}
+	d := &Digest{Items: []Item{
+		{Title: "Go 1.27 released", Link: "https://go.dev/blog/go1.27", Lang: langEnglish},
+		{Title: "Profiling with pprof", Link: "https://example.com/pprof", Lang: langEnglish},
+	}}
+
+	_, err := deliverDigest(context.Background(), cfg, d, &bytes.Buffer{})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests, "each title is translated once for both Japanese targets")
+}